@@ -0,0 +1,110 @@
+// ERB SDK - Airtable Import (hand-written)
+// ========================================
+// Reads LanguageCandidates back from an Airtable JSON export, the tool the
+// rulebook is authored in. Records look like
+//
+//   {"records": [{"id": "rec...", "createdTime": "...", "fields": {"Name": "English", "HasSyntax": true, ...}}]}
+//
+// (a bare array of records is accepted too). Field names are the rulebook's
+// PascalCase names, as in effortless-rulebook.json.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// airtableRecord is one record of an Airtable export
+type airtableRecord struct {
+	Id     string                     `json:"id"`
+	Fields map[string]json.RawMessage `json:"fields"`
+}
+
+// ImportAirtableExport loads LanguageCandidates from an Airtable JSON export.
+// Airtable omits unchecked checkboxes and empty cells, so a missing raw
+// boolean field imports as false and any other missing field as nil.
+// Single selects may be plain strings or {"name": ...} objects, and
+// multi-value cells (multi selects, lookups) are joined with ", ".
+// LanguageCandidateId falls back to the Airtable record id.
+func ImportAirtableExport(path string) ([]LanguageCandidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Airtable export: %w", err)
+	}
+
+	var records []airtableRecord
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("[")) {
+		err = json.Unmarshal(trimmed, &records)
+	} else {
+		var export struct {
+			Records []airtableRecord `json:"records"`
+		}
+		err = json.Unmarshal(trimmed, &export)
+		records = export.Records
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Airtable export: %w", err)
+	}
+
+	candidates := make([]LanguageCandidate, len(records))
+	for i, rec := range records {
+		row := make(map[string]json.RawMessage, len(rec.Fields))
+		for key, value := range rec.Fields {
+			if row[key], err = airtableCellValue(value); err != nil {
+				return nil, fmt.Errorf("record %s field %s: %w", rec.Id, key, err)
+			}
+		}
+
+		lc := &candidates[i]
+		if err := decodeRulebookRow(row, lc); err != nil {
+			return nil, fmt.Errorf("record %s: %w", rec.Id, err)
+		}
+		if lc.LanguageCandidateId == "" {
+			lc.LanguageCandidateId = rec.Id
+		}
+
+		// Unchecked checkboxes are absent from the export
+		fields := fieldsByJSONName(lc)
+		for _, name := range LanguageCandidateRawFields {
+			f := fields[name]
+			if f.Type() == reflect.TypeOf((*bool)(nil)) && f.IsNil() {
+				f.Set(reflect.ValueOf(new(bool)))
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// airtableCellValue converts an Airtable cell to the plain JSON value the
+// struct field expects: {"name": ...} objects become their name, and arrays
+// of scalars become one comma-separated string
+func airtableCellValue(raw json.RawMessage) (json.RawMessage, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		name, ok := t["name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("object cell without a name")
+		}
+		return json.Marshal(name)
+	case []any:
+		parts := make([]string, 0, len(t))
+		for _, item := range t {
+			if obj, ok := item.(map[string]any); ok {
+				item = obj["name"]
+			}
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return json.Marshal(strings.Join(parts, ", "))
+	}
+	return raw, nil
+}
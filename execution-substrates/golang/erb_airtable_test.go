@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestImportAirtableExport(t *testing.T) {
+	candidates, err := ImportAirtableExport("testdata/airtable/export.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("imported %d candidates, want 2", len(candidates))
+	}
+
+	python := candidates[0]
+	if python.LanguageCandidateId != "python" || stringVal(python.DimensionalityWhileEditing) != "OneDimensionalSymbolic" {
+		t.Errorf("python id, single select = %q, %q", python.LanguageCandidateId, stringVal(python.DimensionalityWhileEditing))
+	}
+	// Unchecked checkboxes are absent from the export and import as false
+	if python.CanBeHeld == nil || *python.CanBeHeld || python.IsClosedWorld == nil || *python.IsClosedWorld {
+		t.Errorf("absent checkboxes can_be_held=%s is_closed_world=%s, want false", show3(python.CanBeHeld), show3(python.IsClosedWorld))
+	}
+	want := candidateByID(t, computedCandidates(t), "python")
+	got := python.ComputeAll()
+	if boolVal(got.PredictedAnswer) != boolVal(want.PredictedAnswer) || stringVal(got.RelationshipToConcept) != stringVal(want.RelationshipToConcept) {
+		t.Errorf("imported python computes predicted_answer=%s relationship=%q, want the rulebook's %s, %q",
+			show3(got.PredictedAnswer), stringVal(got.RelationshipToConcept), show3(want.PredictedAnswer), stringVal(want.RelationshipToConcept))
+	}
+
+	mug := candidates[1]
+	if mug.LanguageCandidateId != "recH8i9J0k1L2m3N4" {
+		t.Errorf("id without LanguageCandidateId = %q, want the Airtable record id", mug.LanguageCandidateId)
+	}
+	if got := stringVal(mug.Category); got != "Physical Object, Kitchenware" {
+		t.Errorf("multi-value category = %q", got)
+	}
+	if !boolVal(mug.CanBeHeld) || mug.ModelObjectFacilityLayer != nil {
+		t.Errorf("mug can_be_held=%s model_object_facility_layer=%v, want true, nil", show3(mug.CanBeHeld), mug.ModelObjectFacilityLayer)
+	}
+}
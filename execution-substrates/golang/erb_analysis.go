@@ -0,0 +1,387 @@
+// ERB SDK - Dataset Analysis (hand-written)
+// =========================================
+// Rankings and summaries over computed LanguageCandidates.
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RankedCandidate is one row of the languageness leaderboard
+type RankedCandidate struct {
+	Rank                int     `json:"rank"`
+	LanguageCandidateId string  `json:"language_candidate_id"`
+	Name                *string `json:"name"`
+	PredictionScore     int     `json:"prediction_score"`
+	PredictedAnswer     bool    `json:"predicted_answer"`
+}
+
+// RankByLanguageness ranks the rulebook's candidates by PredictionScore
+// (descending), then name. Equal scores share a rank (standard competition
+// ranking: 1, 2, 2, 4).
+func RankByLanguageness(rb *Rulebook) []RankedCandidate {
+	ranked := make([]RankedCandidate, 0, len(rb.LanguageCandidates))
+	for i := range rb.LanguageCandidates {
+		tc := rb.LanguageCandidates[i].ComputeAll()
+		ranked = append(ranked, RankedCandidate{
+			LanguageCandidateId: tc.LanguageCandidateId,
+			Name:                tc.Name,
+			PredictionScore:     tc.PredictionScore(),
+			PredictedAnswer:     boolVal(tc.PredictedAnswer),
+		})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].PredictionScore != ranked[j].PredictionScore {
+			return ranked[i].PredictionScore > ranked[j].PredictionScore
+		}
+		return stringVal(ranked[i].Name) < stringVal(ranked[j].Name)
+	})
+
+	for i := range ranked {
+		if i > 0 && ranked[i].PredictionScore == ranked[i-1].PredictionScore {
+			ranked[i].Rank = ranked[i-1].Rank
+		} else {
+			ranked[i].Rank = i + 1
+		}
+	}
+	return ranked
+}
+
+// EverythingIsALanguage answers the headline question over a candidate set:
+// true when every candidate is marked IsLanguage, otherwise false plus the
+// ids of the counterexamples. (The name IsEverythingALanguage is taken by
+// the argument table's struct.)
+func EverythingIsALanguage(candidates []LanguageCandidate) (bool, []string) {
+	var counterexamples []string
+	for i := range candidates {
+		if !boolVal(candidates[i].IsLanguage) {
+			counterexamples = append(counterexamples, candidates[i].LanguageCandidateId)
+		}
+	}
+	return len(counterexamples) == 0, counterexamples
+}
+
+// BestCandidate returns the most language-like candidate in the rulebook,
+// computed: highest PredictionScore, then IsLanguage, then name
+func BestCandidate(rb *Rulebook) (LanguageCandidate, error) {
+	if len(rb.LanguageCandidates) == 0 {
+		return LanguageCandidate{}, fmt.Errorf("rulebook has no LanguageCandidates")
+	}
+
+	var best *LanguageCandidate
+	for i := range rb.LanguageCandidates {
+		tc := rb.LanguageCandidates[i].ComputeAll()
+		if best == nil || betterCandidate(tc, best) {
+			best = tc
+		}
+	}
+	return *best, nil
+}
+
+// betterCandidate orders candidates for BestCandidate
+func betterCandidate(a, b *LanguageCandidate) bool {
+	if sa, sb := a.PredictionScore(), b.PredictionScore(); sa != sb {
+		return sa > sb
+	}
+	if la, lb := boolVal(a.IsLanguage), boolVal(b.IsLanguage); la != lb {
+		return la
+	}
+	return stringVal(a.Name) < stringVal(b.Name)
+}
+
+// NoCategory is the RollupByCategory key for candidates without a Category
+const NoCategory = "(none)"
+
+// CategoryRollup aggregates the computed candidates of one Category.
+// IsLanguage is the chosen answer in this rulebook, so Languages doubles as
+// the "chosen" count.
+type CategoryRollup struct {
+	Category               string  `json:"category"`
+	Total                  int     `json:"total"`
+	Languages              int     `json:"languages"`
+	TopAnswers             int     `json:"top_answers"`
+	Mismatches             int     `json:"mismatches"`
+	AveragePredictionScore float64 `json:"average_prediction_score"`
+}
+
+// RollupByCategory computes every candidate in the rulebook and aggregates
+// them per Category; candidates with a nil Category are grouped under NoCategory
+func RollupByCategory(rb *Rulebook) map[string]CategoryRollup {
+	rollups := map[string]CategoryRollup{}
+	scores := map[string]int{}
+	for i := range rb.LanguageCandidates {
+		tc := rb.LanguageCandidates[i].ComputeAll()
+		key := NoCategory
+		if tc.Category != nil {
+			key = *tc.Category
+		}
+
+		r := rollups[key]
+		r.Category = key
+		r.Total++
+		if boolVal(tc.IsLanguage) {
+			r.Languages++
+		}
+		if boolVal(tc.PredictedAnswer) {
+			r.TopAnswers++
+		}
+		if boolVal(tc.PredictedAnswer) != boolVal(tc.IsLanguage) {
+			r.Mismatches++
+		}
+		scores[key] += tc.PredictionScore()
+		rollups[key] = r
+	}
+
+	for key, r := range rollups {
+		r.AveragePredictionScore = float64(scores[key]) / float64(r.Total)
+		rollups[key] = r
+	}
+	return rollups
+}
+
+// VennCounts splits candidates by the two definitions of language: marked
+// IsLanguage and PredictedAnswer (the top answer)
+type VennCounts struct {
+	Both          int `json:"both"`
+	OnlyMarked    int `json:"only_marked"`
+	OnlyPredicted int `json:"only_predicted"`
+	Neither       int `json:"neither"`
+}
+
+// VennSummary counts the candidates in each region of the IsLanguage /
+// PredictedAnswer Venn diagram. Works on raw or computed records.
+func VennSummary(candidates []LanguageCandidate) VennCounts {
+	var counts VennCounts
+	for i := range candidates {
+		marked := boolVal(candidates[i].IsLanguage)
+		predicted := boolVal(candidates[i].ComputeAll().PredictedAnswer)
+		switch {
+		case marked && predicted:
+			counts.Both++
+		case marked:
+			counts.OnlyMarked++
+		case predicted:
+			counts.OnlyPredicted++
+		default:
+			counts.Neither++
+		}
+	}
+	return counts
+}
+
+// ConclusionStepType is the StepType of an argument's concluding step
+const ConclusionStepType = "Conclusion"
+
+// ExtractConclusion returns the single step of an argument whose StepType is
+// ConclusionStepType (compared case-insensitively), and an error when the
+// argument has no conclusion or more than one
+func ExtractConclusion(steps []IsEverythingALanguage) (*IsEverythingALanguage, error) {
+	var conclusion *IsEverythingALanguage
+	for i := range steps {
+		if !strings.EqualFold(stringVal(steps[i].StepType), ConclusionStepType) {
+			continue
+		}
+		if conclusion != nil {
+			return nil, fmt.Errorf("argument has more than one conclusion: %s and %s",
+				conclusion.IsEverythingALanguageId, steps[i].IsEverythingALanguageId)
+		}
+		conclusion = &steps[i]
+	}
+	if conclusion == nil {
+		return nil, fmt.Errorf("argument has no conclusion")
+	}
+	return conclusion, nil
+}
+
+// ArgumentSteps groups the rulebook's argument steps by ArgumentName,
+// keeping step order within each argument
+func ArgumentSteps(rb *Rulebook) map[string][]IsEverythingALanguage {
+	arguments := map[string][]IsEverythingALanguage{}
+	for _, step := range rb.IsEverythingALanguage {
+		name := stringVal(step.ArgumentName)
+		arguments[name] = append(arguments[name], step)
+	}
+	return arguments
+}
+
+// SortCandidates stably sorts views by the named json field, ascending, with
+// language_candidate_id breaking ties so the result does not depend on input
+// order. Nil sorts first, then false before true, numbers, and strings.
+func SortCandidates(views []LanguageCandidate, field string) error {
+	if _, err := Project(nil, []string{field}); err != nil {
+		return err
+	}
+
+	values := make(map[string]any, len(views))
+	for i := range views {
+		values[views[i].LanguageCandidateId] = derefValue(fieldsByJSONName(&views[i])[field])
+	}
+	sort.SliceStable(views, func(i, j int) bool {
+		a, b := values[views[i].LanguageCandidateId], values[views[j].LanguageCandidateId]
+		if c := compareFieldValues(a, b); c != 0 {
+			return c < 0
+		}
+		return views[i].LanguageCandidateId < views[j].LanguageCandidateId
+	})
+	return nil
+}
+
+// compareFieldValues orders two field values of the same field
+func compareFieldValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	switch x := a.(type) {
+	case bool:
+		y := b.(bool)
+		if x == y {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case int:
+		return cmp.Compare(x, b.(int))
+	case string:
+		return strings.Compare(x, b.(string))
+	}
+	return 0
+}
+
+// CalcFieldCoverage is how well a set of test records exercises one
+// calculated field
+type CalcFieldCoverage struct {
+	Table      string `json:"table"`
+	Field      string `json:"field"`
+	Records    int    `json:"records"`     // computed records of the table
+	NonDefault int    `json:"non_default"` // records where the field is not null, false, 0 or ""
+	Distinct   int    `json:"distinct"`    // distinct computed values, null included
+}
+
+// Covered reports whether some record computes a non-default value
+func (c CalcFieldCoverage) Covered() bool { return c.NonDefault > 0 }
+
+// Constant reports whether every record computes the same value, so the
+// records cannot tell the formula apart from a constant
+func (c CalcFieldCoverage) Constant() bool { return c.Records > 0 && c.Distinct == 1 }
+
+// CoverageReport is FieldCoverage's result
+type CoverageReport struct {
+	Fields  []CalcFieldCoverage `json:"fields"`
+	Ignored int                 `json:"ignored"` // arguments that were not generated table records
+}
+
+// Fraction is the share of calculated fields that are covered (0 when the
+// rulebook declares none)
+func (r CoverageReport) Fraction() float64 {
+	if len(r.Fields) == 0 {
+		return 0
+	}
+	covered := 0
+	for _, f := range r.Fields {
+		if f.Covered() {
+			covered++
+		}
+	}
+	return float64(covered) / float64(len(r.Fields))
+}
+
+// FieldCoverage computes records (generated records or slices of them, by
+// value or pointer) and reports, for every calculated field the rulebook
+// declares across all tables, how many of them exercise it. Fields of
+// tables with no records are uncovered. Records are computed on copies.
+func FieldCoverage(rb *Rulebook, records ...any) CoverageReport {
+	var report CoverageReport
+	computed := map[string][]map[string]reflect.Value{}
+	add := func(v reflect.Value) {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				report.Ignored++
+				return
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			report.Ignored++
+			return
+		}
+		c := reflect.New(v.Type())
+		c.Elem().Set(v)
+		r, ok := c.Interface().(Record)
+		if !ok {
+			report.Ignored++
+			return
+		}
+		computed[r.TableName()] = append(computed[r.TableName()], fieldsByJSONName(r.Compute()))
+	}
+	for _, arg := range records {
+		v := reflect.ValueOf(arg)
+		if v.Kind() == reflect.Slice {
+			for i := 0; i < v.Len(); i++ {
+				add(v.Index(i))
+			}
+			continue
+		}
+		if !v.IsValid() {
+			report.Ignored++
+			continue
+		}
+		add(v)
+	}
+
+	for _, table := range ExpectedTables(rb) {
+		var calculated []FieldDef
+		for _, f := range rb.Tables[table].Schema {
+			if f.Type == "calculated" {
+				calculated = append(calculated, f)
+			}
+		}
+		for _, field := range schemaJSONNames(table, calculated) {
+			c := CalcFieldCoverage{Table: table, Field: field, Records: len(computed[table])}
+			distinct := map[any]bool{}
+			for _, fields := range computed[table] {
+				value := derefValue(fields[field])
+				distinct[value] = true
+				if value != nil && !reflect.ValueOf(value).IsZero() {
+					c.NonDefault++
+				}
+			}
+			c.Distinct = len(distinct)
+			report.Fields = append(report.Fields, c)
+		}
+	}
+	return report
+}
+
+// FindCounterexamples returns the computed views of the rulebook's
+// candidates that are not languages (IsLanguage false or null): concrete
+// disproofs of "everything is a language". The closest calls come first,
+// ordered by how many PredictionConditions they fail, then by id.
+func FindCounterexamples(rb *Rulebook) []LanguageCandidate {
+	var found []LanguageCandidate
+	for view := range Views(rb.LanguageCandidates) {
+		if !boolVal(view.IsLanguage) {
+			found = append(found, view)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		fi, fj := len(found[i].FailedConditions()), len(found[j].FailedConditions())
+		if fi != fj {
+			return fi < fj
+		}
+		return found[i].LanguageCandidateId < found[j].LanguageCandidateId
+	})
+	return found
+}
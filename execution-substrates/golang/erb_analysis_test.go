@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRankByLanguagenessSharesTiedRanks(t *testing.T) {
+	candidate := func(id, name string, edit func(tc *LanguageCandidate)) LanguageCandidate {
+		tc := topAnswer()
+		tc.LanguageCandidateId, tc.Name = id, ptr(name)
+		edit(&tc)
+		return tc
+	}
+	rb := &Rulebook{LanguageCandidates: []LanguageCandidate{
+		candidate("six", "Far", func(tc *LanguageCandidate) { tc.HasSyntax, tc.IsParsed = ptr(false), ptr(false) }),
+		candidate("seven-b", "Beta", func(tc *LanguageCandidate) { tc.CanBeHeld = ptr(true) }),
+		candidate("eight", "Top", func(tc *LanguageCandidate) {}),
+		candidate("seven-a", "Alpha", func(tc *LanguageCandidate) { tc.HasIdentity = ptr(true) }),
+	}}
+
+	var got []string
+	for _, r := range RankByLanguageness(rb) {
+		got = append(got, fmt.Sprintf("%d %s %d %t", r.Rank, r.LanguageCandidateId, r.PredictionScore, r.PredictedAnswer))
+	}
+	want := []string{"1 eight 8 true", "2 seven-a 7 false", "2 seven-b 7 false", "4 six 6 false"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("RankByLanguageness = %q, want %q", got, want)
+	}
+}
+
+func TestEverythingIsALanguage(t *testing.T) {
+	candidate := func(id string, isLanguage *bool) LanguageCandidate {
+		return LanguageCandidate{LanguageCandidateId: id, IsLanguage: isLanguage}
+	}
+
+	all, counterexamples := EverythingIsALanguage([]LanguageCandidate{candidate("english", ptr(true)), candidate("python", ptr(true))})
+	if !all || counterexamples != nil {
+		t.Errorf("all languages = %t, %v, want true, []", all, counterexamples)
+	}
+
+	mixed := []LanguageCandidate{candidate("english", ptr(true)), candidate("a-rock", ptr(false)), candidate("python", ptr(true)), candidate("unknown", nil)}
+	all, counterexamples = EverythingIsALanguage(mixed)
+	if all || fmt.Sprint(counterexamples) != "[a-rock unknown]" {
+		t.Errorf("mixed = %t, %v, want false, [a-rock unknown]", all, counterexamples)
+	}
+}
+
+func TestVennSummary(t *testing.T) {
+	candidate := func(isLanguage, predicted bool) LanguageCandidate {
+		tc := topAnswer()
+		tc.IsLanguage = ptr(isLanguage)
+		tc.CanBeHeld = ptr(!predicted)
+		return tc
+	}
+	candidates := []LanguageCandidate{
+		candidate(true, true),
+		candidate(true, false),
+		candidate(false, true),
+		candidate(false, true),
+		candidate(false, false),
+		candidate(false, false),
+		candidate(false, false),
+	}
+	want := VennCounts{Both: 1, OnlyMarked: 1, OnlyPredicted: 2, Neither: 3}
+	if got := VennSummary(candidates); got != want {
+		t.Errorf("VennSummary = %+v, want %+v", got, want)
+	}
+}
+
+func TestExtractConclusion(t *testing.T) {
+	step := func(id, stepType string) IsEverythingALanguage {
+		return IsEverythingALanguage{IsEverythingALanguageId: id, StepType: ptr(stepType)}
+	}
+	tests := []struct {
+		name  string
+		steps []IsEverythingALanguage
+		want  string // conclusion id, or "" for an error
+	}{
+		{"zero", []IsEverythingALanguage{step("p1", "Premise"), step("p2", "Premise")}, ""},
+		{"one", []IsEverythingALanguage{step("p1", "Premise"), step("c1", "conclusion")}, "c1"},
+		{"two", []IsEverythingALanguage{step("c1", "Conclusion"), step("p1", "Premise"), step("c2", "Conclusion")}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractConclusion(tt.steps)
+			switch {
+			case tt.want == "" && err == nil:
+				t.Errorf("ExtractConclusion = %s, want an error", got.IsEverythingALanguageId)
+			case tt.want != "" && (err != nil || got.IsEverythingALanguageId != tt.want):
+				t.Errorf("ExtractConclusion = %v, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+
+	for name, steps := range ArgumentSteps(loadTestRulebook(t)) {
+		if _, err := ExtractConclusion(steps); err != nil {
+			t.Errorf("rulebook argument %q: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// ERB SDK - Columnar Export (hand-written)
+// =======================================
+// Computed LanguageCandidates as typed, nullable columns laid out like
+// Apache Arrow arrays: an LSB-first validity bitmap per column, bit-packed
+// booleans, int64 values, and int32 offsets into UTF-8 data for strings.
+// The buffers can be handed to an Arrow library (array.NewData) as-is;
+// this substrate has no module manifest to pull one in.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// ArrowType is the Arrow logical type of a column
+type ArrowType string
+
+const (
+	ArrowBool   ArrowType = "bool"
+	ArrowInt64  ArrowType = "int64"
+	ArrowString ArrowType = "utf8"
+)
+
+// ArrowField describes one column of an ArrowTable
+type ArrowField struct {
+	Name     string
+	Type     ArrowType
+	Nullable bool
+}
+
+// ArrowColumn holds one column's buffers in Arrow layout. Values is the
+// bit-packed booleans for ArrowBool and nil otherwise.
+type ArrowColumn struct {
+	Validity  []byte // bit i set when row i is not null
+	NullCount int
+	Values    []byte  // ArrowBool values bitmap
+	Int64s    []int64 // ArrowInt64 values (0 where null)
+	Offsets   []int32 // ArrowString offsets, len rows+1
+	Data      []byte  // ArrowString UTF-8 bytes
+}
+
+// ArrowTable is a set of equal-length columns with their schema
+type ArrowTable struct {
+	Schema  []ArrowField
+	Columns []ArrowColumn
+	NumRows int
+}
+
+// ToArrowTable lays views out column by column, one column per json field
+// in struct order. Pointer fields are nullable; the id column is not.
+func ToArrowTable(views []LanguageCandidate) (ArrowTable, error) {
+	t := reflect.TypeOf(LanguageCandidate{})
+	table := ArrowTable{NumRows: len(views)}
+	bitmapLen := (len(views) + 7) / 8
+
+	for _, nv := range recordValues(&LanguageCandidate{}) {
+		sf, _ := t.FieldByName(goFieldName(LanguageCandidate{}, nv.Name))
+		field := ArrowField{Name: nv.Name, Nullable: sf.Type.Kind() == reflect.Pointer}
+		kind := sf.Type.Kind()
+		if field.Nullable {
+			kind = sf.Type.Elem().Kind()
+		}
+		switch kind {
+		case reflect.Bool:
+			field.Type = ArrowBool
+		case reflect.Int:
+			field.Type = ArrowInt64
+		case reflect.String:
+			field.Type = ArrowString
+		default:
+			return ArrowTable{}, fmt.Errorf("field %s: unsupported kind %s", nv.Name, kind)
+		}
+
+		col := ArrowColumn{Validity: make([]byte, bitmapLen)}
+		switch field.Type {
+		case ArrowBool:
+			col.Values = make([]byte, bitmapLen)
+		case ArrowInt64:
+			col.Int64s = make([]int64, len(views))
+		case ArrowString:
+			col.Offsets = make([]int32, 1, len(views)+1)
+		}
+
+		for i := range views {
+			value := derefValue(fieldsByJSONName(&views[i])[nv.Name])
+			if value == nil {
+				col.NullCount++
+			} else {
+				col.Validity[i/8] |= 1 << (i % 8)
+			}
+			switch v := value.(type) {
+			case bool:
+				if v {
+					col.Values[i/8] |= 1 << (i % 8)
+				}
+			case int:
+				col.Int64s[i] = int64(v)
+			case string:
+				col.Data = append(col.Data, v...)
+			}
+			if field.Type == ArrowString {
+				if len(col.Data) > math.MaxInt32 {
+					return ArrowTable{}, fmt.Errorf("field %s: string data exceeds int32 offsets", nv.Name)
+				}
+				col.Offsets = append(col.Offsets, int32(len(col.Data)))
+			}
+		}
+
+		table.Schema = append(table.Schema, field)
+		table.Columns = append(table.Columns, col)
+	}
+	return table, nil
+}
+
+// Value returns row i of column c as bool, int64, string, or nil when null
+func (t ArrowTable) Value(c, i int) any {
+	col := t.Columns[c]
+	if col.Validity[i/8]&(1<<(i%8)) == 0 {
+		return nil
+	}
+	switch t.Schema[c].Type {
+	case ArrowBool:
+		return col.Values[i/8]&(1<<(i%8)) != 0
+	case ArrowInt64:
+		return col.Int64s[i]
+	default:
+		return string(col.Data[col.Offsets[i]:col.Offsets[i+1]])
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToArrowTable(t *testing.T) {
+	computed := computedCandidates(t)
+	views := []LanguageCandidate{
+		candidateByID(t, computed, "python"),
+		candidateByID(t, computed, "a-coffee-mug"),
+		{LanguageCandidateId: "blank"},
+	}
+	table, err := ToArrowTable(views)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.NumRows != 3 || len(table.Columns) != len(table.Schema) {
+		t.Fatalf("NumRows = %d, %d columns for %d fields", table.NumRows, len(table.Columns), len(table.Schema))
+	}
+
+	column := func(name string) int {
+		for i, f := range table.Schema {
+			if f.Name == name {
+				return i
+			}
+		}
+		t.Fatalf("no column %s", name)
+		return -1
+	}
+	wantSchema := map[string]ArrowField{
+		"language_candidate_id": {"language_candidate_id", ArrowString, false},
+		"predicted_answer":      {"predicted_answer", ArrowBool, true},
+		"distance_from_concept": {"distance_from_concept", ArrowInt64, true},
+		"name":                  {"name", ArrowString, true},
+	}
+	for name, want := range wantSchema {
+		if got := table.Schema[column(name)]; got != want {
+			t.Errorf("schema %s = %+v, want %+v", name, got, want)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		want  []any
+		nulls int
+	}{
+		{"language_candidate_id", []any{"python", "a-coffee-mug", "blank"}, 0},
+		{"predicted_answer", []any{true, false, nil}, 1},
+		{"distance_from_concept", []any{int64(2), int64(*views[1].DistanceFromConcept), nil}, 1},
+		{"name", []any{"Python", "A Coffee Mug", nil}, 1},
+	}
+	for _, tt := range tests {
+		c := column(tt.name)
+		var got []any
+		for i := range views {
+			got = append(got, table.Value(c, i))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("column %s = %v, want %v", tt.name, got, tt.want)
+		}
+		if nulls := table.Columns[c].NullCount; nulls != tt.nulls {
+			t.Errorf("column %s NullCount = %d, want %d", tt.name, nulls, tt.nulls)
+		}
+	}
+}
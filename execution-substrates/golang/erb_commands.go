@@ -0,0 +1,1058 @@
+// ERB SDK - Command Line Subcommands (hand-written)
+// =================================================
+// main.go (generated) dispatches here when given arguments:
+//
+//   go run . <command> [args...]
+//
+// With no arguments main.go takes the test as before.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Default locations, relative to this substrate directory unless
+// overridden by the same environment variables as main.go
+var (
+	defaultBlankTestsDir  = envDir("ERB_BLANK_TESTS_DIR", filepath.Join("..", "..", "testing", "blank-tests"))
+	defaultTestAnswersDir = envDir("ERB_TEST_ANSWERS_DIR", "test-answers")
+)
+
+// envDir returns the environment variable's value, or fallback when it is unset or empty
+func envDir(name, fallback string) string {
+	if dir := os.Getenv(name); dir != "" {
+		return dir
+	}
+	return fallback
+}
+
+// command is a subcommand; run returns the process exit code
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]command{
+	"best":                      {"best", cmdBest},
+	"compare":                   {"compare <language_candidate_id> <language_candidate_id>", cmdCompare},
+	"conclusions":               {"conclusions", cmdConclusions},
+	"counterexamples":           {"counterexamples", cmdCounterexamples},
+	"coverage":                  {"coverage [-in blank.json]", cmdCoverage},
+	"dag":                       {"dag [-format text|json]", cmdDAG},
+	"diff-answers":              {"diff-answers <a.json> <b.json>", cmdDiffAnswers},
+	"export-prolog":             {"export-prolog [-out kb.pl]", cmdExportProlog},
+	"held-language-categories":  {"held-language-categories [-in answers.json]", cmdHeldLanguageCategories},
+	"jq-patch":                  {"jq-patch <before.json> <after.json>", cmdJQPatch},
+	"make-blank":                {"make-blank [-ids a,b [-in answers.json]] <out.json>", cmdMakeBlank},
+	"name-category-overlap":     {"name-category-overlap", cmdNameCategoryOverlap},
+	"near-misses":               {"near-misses", cmdNearMisses},
+	"parity":                    {"parity [-in blank.json] <dump.csv|dump.sql>", cmdParity},
+	"process-parallel":          {"process-parallel [-in dir] [-out dir] [-table-workers 2] [-record-workers n]", cmdProcessParallel},
+	"relationship-mismatches":   {"relationship-mismatches [-in answers.json]", cmdRelationshipMismatches},
+	"rollup":                    {"rollup [-json]", cmdRollup},
+	"run-datasets":              {"run-datasets [-in dir] [-out dir] [name...]", cmdRunDatasets},
+	"verify-blank":              {"verify-blank [blank.json]", cmdVerifyBlank},
+	"verify-chosen-consistency": {"verify-chosen-consistency", cmdVerifyChosenConsistency},
+	"verify-generated":          {"verify-generated", cmdVerifyGenerated},
+	"verify-precomputed":        {"verify-precomputed [-in answers.json]", cmdVerifyPrecomputed},
+	"verify-runner":             {"verify-runner", cmdVerifyRunner},
+	"verify-test":               {"verify-test [-in blank.json] [-expected expected-answers.json]", cmdVerifyTest},
+	"take-test":                 {"take-test [-in blank.json] [-flex-bools | -templates] [-defaults defaults.json] [-shuffle-input [-seed 1]] [-sort-by field] [-record-timeout 1s] [-out-json f] [-out-csv f [-columns f1,f2]] [-bool-format native|numeric|yesno] [-out-yaml f] [-out-proto f] [-project f1,f2 -out f] [-check golden.json] [-force]", cmdTakeTest},
+}
+
+// runCommand runs the named subcommand, printing usage for unknown names
+func runCommand(name string, args []string) int {
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\nCommands:\n", name)
+		names := make([]string, 0, len(commands))
+		for n := range commands {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			fmt.Fprintf(os.Stderr, "  %s\n", commands[n].usage)
+		}
+		return 2
+	}
+	return cmd.run(args)
+}
+
+// loadDefaultRulebook loads the rulebook, from the ERB_RULEBOOK_URL
+// environment variable when it is set, reporting failures on stderr
+func loadDefaultRulebook() (*Rulebook, bool) {
+	var rb *Rulebook
+	var err error
+	if url := os.Getenv("ERB_RULEBOOK_URL"); url != "" {
+		rb, err = LoadFromRulebookURL(context.Background(), url)
+	} else {
+		rb, err = LoadFromRulebook(DefaultRulebookPath)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return nil, false
+	}
+	return rb, true
+}
+
+// cmdBest prints the most language-like candidate as a card
+func cmdBest(args []string) int {
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+	best, err := BestCandidate(rb)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	verdict := "not a language"
+	if boolVal(best.PredictedAnswer) {
+		verdict = "a language"
+	}
+	fmt.Println("════════════════════════════════════════════════════════════════")
+	fmt.Printf("  %s\n", displayValue(best.Name))
+	fmt.Println("────────────────────────────────────────────────────────────────")
+	fmt.Printf("  Q: %s\n", displayValue(best.Question))
+	fmt.Printf("  A: %s (%d/%d conditions)\n", verdict, best.PredictionScore(), len(PredictionConditions))
+	fmt.Printf("  Category: %s\n", displayValue(best.Category))
+	fmt.Println("════════════════════════════════════════════════════════════════")
+	return 0
+}
+
+// cmdCompare prints two rulebook candidates' computed fields side by side,
+// marking the rows that differ with *
+func cmdCompare(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: compare <language_candidate_id> <language_candidate_id>")
+		return 2
+	}
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	views := make([]LanguageCandidate, len(args))
+	for i, id := range args {
+		j := slices.IndexFunc(rb.LanguageCandidates, func(lc LanguageCandidate) bool { return lc.LanguageCandidateId == id })
+		if j < 0 {
+			fmt.Fprintf(os.Stderr, "ERROR: no candidate %q\n", id)
+			return 1
+		}
+		views[i] = *rb.LanguageCandidates[j].ComputeView()
+	}
+
+	pairs := CompareCandidates(views[0], views[1])
+	differing := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, " \tFIELD\t%s\t%s\n", args[0], args[1])
+	for _, p := range pairs {
+		mark := " "
+		if p.Differs {
+			mark = "*"
+			differing++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", mark, p.Field, displayValue(p.A), displayValue(p.B))
+	}
+	w.Flush()
+	fmt.Printf("%d of %d fields differ\n", differing, len(pairs))
+	return 0
+}
+
+// cmdConclusions prints each argument's conclusion; exits 1 when an
+// argument has none or several
+func cmdConclusions(args []string) int {
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	arguments := ArgumentSteps(rb)
+	names := make([]string, 0, len(arguments))
+	for name := range arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status := 0
+	for _, name := range names {
+		conclusion, err := ExtractConclusion(arguments[name])
+		if err != nil {
+			fmt.Printf("%s: ERROR: %v\n", name, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %s\n", name, displayValue(conclusion.Statement))
+	}
+	return status
+}
+
+// cmdCounterexamples lists the candidates that are not languages, closest calls first
+func cmdCounterexamples(args []string) int {
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	found := FindCounterexamples(rb)
+	for i := range found {
+		failed := found[i].FailedConditions()
+		fmt.Printf("%s (%s): fails %d", found[i].LanguageCandidateId, displayValue(found[i].Name), len(failed))
+		if len(failed) > 0 {
+			fmt.Printf(": %s", strings.Join(failed, ", "))
+		}
+		fmt.Println()
+	}
+	fmt.Printf("%d of %d candidates are not languages\n", len(found), len(rb.LanguageCandidates))
+	return 0
+}
+
+// cmdCoverage reports how well a blank test exercises each calculated field
+func cmdCoverage(args []string) int {
+	fs := flag.NewFlagSet("coverage", flag.ContinueOnError)
+	in := fs.String("in", filepath.Join(defaultBlankTestsDir, "language_candidates.json"), "test fixtures to measure")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+	records, err := LoadLanguageCandidateRecords(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	report := FieldCoverage(rb, records)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tRECORDS\tNON-DEFAULT\tDISTINCT\tNOTE")
+	for _, f := range report.Fields {
+		note := ""
+		switch {
+		case !f.Covered():
+			note = "NOT COVERED"
+		case f.Constant():
+			note = "CONSTANT"
+		}
+		fmt.Fprintf(w, "%s.%s\t%d\t%d\t%d\t%s\n", f.Table, f.Field, f.Records, f.NonDefault, f.Distinct, note)
+	}
+	w.Flush()
+	fmt.Printf("%.0f%% of %d calculated fields covered\n", report.Fraction()*100, len(report.Fields))
+	return 0
+}
+
+// cmdDAG prints the calculated-field dependency graph, as one line per
+// calculated field in evaluation order or as JSON for external tools
+func cmdDAG(args []string) int {
+	fs := flag.NewFlagSet("dag", flag.ContinueOnError)
+	format := fs.String("format", "text", "text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	g := LanguageCandidateDependencyGraph()
+	switch *format {
+	case "text":
+		for _, name := range g.Calculated {
+			fmt.Printf("%s <- %s\n", name, strings.Join(g.Deps[name], ", "))
+		}
+	case "json":
+		data, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Fprintf(os.Stderr, "dag: -format must be text or json, not %q\n", *format)
+		return 2
+	}
+	return 0
+}
+
+// cmdDiffAnswers prints the field-by-field differences between two
+// test-answers files, e.g. this substrate's and another's; exits 1 when
+// there are any
+func cmdDiffAnswers(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: diff-answers <a.json> <b.json>")
+		return 2
+	}
+	sides := make([][]AnswerRow, 2)
+	for i, path := range args {
+		rows, err := LoadAnswerRows(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		sides[i] = rows
+	}
+	diffs, err := DiffAnswers(sides[0], sides[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("No differences")
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tFIELD\t%s\t%s\n", args[0], args[1])
+	for _, d := range diffs {
+		switch d.Missing {
+		case "a":
+			fmt.Fprintf(w, "%s\t(record)\tmissing\tpresent\n", d.LanguageCandidateId)
+		case "b":
+			fmt.Fprintf(w, "%s\t(record)\tpresent\tmissing\n", d.LanguageCandidateId)
+		default:
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.LanguageCandidateId, d.Field, displayValue(d.A), displayValue(d.B))
+		}
+	}
+	w.Flush()
+	fmt.Printf("%d difference(s)\n", len(diffs))
+	return 1
+}
+
+// cmdExportProlog writes the rulebook as a Prolog knowledge base
+func cmdExportProlog(args []string) int {
+	fs := flag.NewFlagSet("export-prolog", flag.ContinueOnError)
+	out := fs.String("out", "", "write to this file instead of stdout")
+	fs.BoolVar(&ForceOverwrite, "force", false, "allow writing to protected paths such as *.go")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	kb := RenderPrologKB(rb)
+	if *out == "" {
+		fmt.Print(kb)
+		return 0
+	}
+	if err := checkOutputPath(*out); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*out, []byte(kb), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// cmdHeldLanguageCategories lists candidates filed under a language
+// category that can nonetheless be held; reads the rulebook unless -in is
+// given. Exits 1 if there are any.
+func cmdHeldLanguageCategories(args []string) int {
+	fs := flag.NewFlagSet("held-language-categories", flag.ContinueOnError)
+	in := fs.String("in", "", "candidates file to check instead of the rulebook")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var candidates []LanguageCandidate
+	if *in != "" {
+		records, err := LoadLanguageCandidateRecords(*in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		candidates = records
+	} else {
+		rb, ok := loadDefaultRulebook()
+		if !ok {
+			return 1
+		}
+		candidates = rb.LanguageCandidates
+	}
+
+	ids := FindHeldLanguageCategories(candidates)
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+	fmt.Printf("%d held candidates in a language category\n", len(ids))
+	if len(ids) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdJQPatch prints the jq program that turns one answers file into another
+func cmdJQPatch(args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: jq-patch <before.json> <after.json>")
+		return 2
+	}
+	before, err := LoadLanguageCandidateRecords(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	after, err := LoadLanguageCandidateRecords(args[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	program, err := ComputeJQPatch(before, after)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	fmt.Print(program)
+	return 0
+}
+
+// cmdMakeBlank writes a blank test: the whole rulebook, or with -ids a
+// fixture of just those candidates taken from the rulebook or -in
+func cmdMakeBlank(args []string) int {
+	fs := flag.NewFlagSet("make-blank", flag.ContinueOnError)
+	ids := fs.String("ids", "", "comma-separated language_candidate_ids to extract as a fixture")
+	in := fs.String("in", "", "candidates file to extract -ids from instead of the rulebook")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 || (*in != "" && *ids == "") {
+		fmt.Fprintln(os.Stderr, "usage: make-blank [-ids a,b [-in answers.json]] <out.json>")
+		return 2
+	}
+	out := fs.Arg(0)
+
+	var blank []LanguageCandidate
+	if *in != "" {
+		records, err := LoadLanguageCandidateRecords(*in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		if blank, err = ExtractFixture(records, strings.Split(*ids, ",")); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+	} else {
+		rb, ok := loadDefaultRulebook()
+		if !ok {
+			return 1
+		}
+		if *ids == "" {
+			blank = GenerateBlankTest(rb)
+		} else {
+			var err error
+			if blank, err = ExtractFixture(rb.LanguageCandidates, strings.Split(*ids, ",")); err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	if err := SaveLanguageCandidateRecords(out, blank); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d blank LanguageCandidates to %s\n", len(blank), out)
+	return 0
+}
+
+// cmdNameCategoryOverlap prints candidates whose Name and Category overlap; exits 1 if any do
+func cmdNameCategoryOverlap(args []string) int {
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	suspects := SuspectNameCategoryOverlap(rb)
+	for _, s := range suspects {
+		fmt.Println(s)
+	}
+	fmt.Printf("%d suspect name/category overlaps\n", len(suspects))
+	if len(suspects) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdParity computes the blank test and compares it with a postgres export of
+// vw_language_candidates; exits 1 on any divergence
+func cmdParity(args []string) int {
+	fs := flag.NewFlagSet("parity", flag.ContinueOnError)
+	in := fs.String("in", filepath.Join(defaultBlankTestsDir, "language_candidates.json"), "blank test to compute")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: parity [-in blank.json] <dump.csv|dump.sql>")
+		return 2
+	}
+
+	records, err := LoadLanguageCandidateRecords(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	computed := make([]LanguageCandidate, 0, len(records))
+	for i := range records {
+		computed = append(computed, *records[i].ComputeAll())
+	}
+
+	diffs, err := CheckParityWithSQLDump(computed, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	for _, d := range diffs {
+		fmt.Println(d)
+	}
+	fmt.Printf("%d divergences from %s\n", len(diffs), fs.Arg(0))
+	if len(diffs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdRelationshipMismatches lists candidates whose precomputed relationship_to_concept
+// disagrees with their distance_from_concept; reads the rulebook unless -in is given.
+// Exits 1 if any disagree.
+func cmdRelationshipMismatches(args []string) int {
+	fs := flag.NewFlagSet("relationship-mismatches", flag.ContinueOnError)
+	in := fs.String("in", "", "candidates file to check instead of the rulebook")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var candidates []LanguageCandidate
+	if *in != "" {
+		records, err := LoadLanguageCandidateRecords(*in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		candidates = records
+	} else {
+		rb, ok := loadDefaultRulebook()
+		if !ok {
+			return 1
+		}
+		candidates = rb.LanguageCandidates
+	}
+
+	ids := RelationshipDistanceMismatches(candidates)
+	for _, id := range ids {
+		fmt.Println(id)
+	}
+	fmt.Printf("%d relationship/distance mismatches\n", len(ids))
+	if len(ids) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdRollup prints the per-category rollup as a table, or as JSON with -json
+func cmdRollup(args []string) int {
+	fs := flag.NewFlagSet("rollup", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	rollups := RollupByCategory(rb)
+	keys := make([]string, 0, len(rollups))
+	for k := range rollups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if *asJSON {
+		rows := make([]CategoryRollup, 0, len(keys))
+		for _, k := range keys {
+			rows = append(rows, rollups[k])
+		}
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tTOTAL\tLANGUAGES\tTOP ANSWERS\tMISMATCHES\tAVG SCORE")
+	for _, k := range keys {
+		r := rollups[k]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.2f\n", r.Category, r.Total, r.Languages, r.TopAnswers, r.Mismatches, r.AveragePredictionScore)
+	}
+	w.Flush()
+	return 0
+}
+
+// cmdNearMisses lists candidates one condition away from being top answers
+func cmdNearMisses(args []string) int {
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	misses := NearMisses(rb)
+	for _, m := range misses {
+		fmt.Printf("%s (%s): needs %s = %t\n", m.LanguageCandidateId, displayValue(m.Name), m.Condition, m.NeededValue)
+	}
+	fmt.Printf("%d near misses\n", len(misses))
+	return 0
+}
+
+// cmdRunDatasets runs the named datasets of DefaultDatasets, or all of
+// them; exits 1 when any fails
+func cmdRunDatasets(args []string) int {
+	fs := flag.NewFlagSet("run-datasets", flag.ContinueOnError)
+	in := fs.String("in", defaultBlankTestsDir, "blank tests directory")
+	out := fs.String("out", defaultTestAnswersDir, "test answers directory")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	registry := DefaultDatasets(*in, *out)
+	if fs.NArg() == 0 {
+		results, err := registry.RunAll()
+		for _, r := range results {
+			if r.Err == nil {
+				fmt.Printf("  ✓ %s: %d records processed\n", r.Table, r.Records)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	status := 0
+	for _, name := range fs.Args() {
+		n, err := registry.Run(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			status = 1
+			continue
+		}
+		fmt.Printf("  ✓ %s: %d records processed\n", name, n)
+	}
+	return status
+}
+
+// cmdVerifyBlank checks that a blank test has no calculated fields filled in
+func cmdVerifyBlank(args []string) int {
+	path := filepath.Join(defaultBlankTestsDir, "language_candidates.json")
+	switch len(args) {
+	case 0:
+	case 1:
+		path = args[0]
+	default:
+		fmt.Fprintln(os.Stderr, "usage: verify-blank [blank.json]")
+		return 2
+	}
+
+	if err := AssertBlankTest(path); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%s is blank\n", path)
+	return 0
+}
+
+// cmdVerifyChosenConsistency lists candidates marked IsLanguage whose raw
+// flags contradict it; exits 1 when there are any
+func cmdVerifyChosenConsistency(args []string) int {
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	anomalies := ChosenWithContradictions(rb)
+	for _, a := range anomalies {
+		fmt.Printf("%s (%s): %s\n", a.LanguageCandidateId, displayValue(a.Name), strings.Join(a.Contradictions, "; "))
+	}
+	fmt.Printf("%d chosen candidates with contradictions\n", len(anomalies))
+	if len(anomalies) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdProcessParallel takes the test like main.go, with tables and records
+// processed concurrently; exits 1 when any table fails
+func cmdProcessParallel(args []string) int {
+	fs := flag.NewFlagSet("process-parallel", flag.ContinueOnError)
+	in := fs.String("in", defaultBlankTestsDir, "blank tests directory")
+	out := fs.String("out", defaultTestAnswersDir, "test answers directory")
+	tableWorkers := fs.Int("table-workers", 2, "tables processed at once")
+	recordWorkers := fs.Int("record-workers", MaxRecordWorkers, "record goroutines shared by all tables")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	MaxRecordWorkers = *recordWorkers
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	results, err := ProcessAllTablesParallel(*in, *out, *tableWorkers)
+	for _, r := range results {
+		if r.Err == nil {
+			fmt.Printf("  ✓ %s: %d records processed\n", r.Table, r.Records)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// cmdVerifyGenerated checks erb_sdk.go against the rulebook and generator
+// it was generated from (VerifyGeneratedChecksum); exits 1 when stale
+func cmdVerifyGenerated(args []string) int {
+	if err := VerifyGeneratedChecksum(DefaultRulebookPath, GeneratorSources); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	fmt.Println("erb_sdk.go matches the rulebook and generator it was generated from")
+	return 0
+}
+
+// cmdVerifyPrecomputed compares the calculated fields an input already
+// carries with the computed ones; exits 1 when any disagree
+func cmdVerifyPrecomputed(args []string) int {
+	fs := flag.NewFlagSet("verify-precomputed", flag.ContinueOnError)
+	in := fs.String("in", "", "candidates file to check instead of the rulebook")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	var candidates []LanguageCandidate
+	if *in != "" {
+		records, err := LoadLanguageCandidateRecords(*in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		candidates = records
+	} else {
+		rb, ok := loadDefaultRulebook()
+		if !ok {
+			return 1
+		}
+		candidates = rb.LanguageCandidates
+	}
+
+	count := 0
+	for i := range candidates {
+		for _, d := range VerifyPrecomputed(candidates[i]) {
+			fmt.Printf("%s.%s: input=%s computed=%s\n", candidates[i].LanguageCandidateId, d.Field, displayValue(d.Input), displayValue(d.Computed))
+			count++
+		}
+	}
+	fmt.Printf("%d precomputed fields disagree\n", count)
+	if count > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdVerifyTest computes a blank test with ComputeAll, like take-test (post
+// processors are view-only and not applied), but instead of writing answers
+// compares them with known-good answers; exits 1 with the differences
+// grouped by candidate when there are any
+func cmdVerifyTest(args []string) int {
+	fs := flag.NewFlagSet("verify-test", flag.ContinueOnError)
+	in := fs.String("in", filepath.Join(defaultBlankTestsDir, "language_candidates.json"), "blank test to compute")
+	expectedPath := fs.String("expected", "expected-answers.json", "known-good answers to compare with")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	records, err := LoadLanguageCandidateRecords(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	computed := make([]LanguageCandidate, len(records))
+	for i := range records {
+		computed[i] = *records[i].ComputeAll()
+	}
+	actual, err := AnswerRowsOf(computed)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	expected, err := LoadAnswerRows(*expectedPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	diffs, err := DiffAnswers(expected, actual)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("✓ %d records match %s\n", len(actual), *expectedPath)
+		return 0
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	candidates := 0
+	for i, d := range diffs {
+		if i == 0 || d.LanguageCandidateId != diffs[i-1].LanguageCandidateId {
+			fmt.Fprintf(w, "%s\n", d.LanguageCandidateId)
+			candidates++
+		}
+		switch d.Missing {
+		case "a":
+			fmt.Fprintf(w, "  (record)\texpected=missing\tactual=present\n")
+		case "b":
+			fmt.Fprintf(w, "  (record)\texpected=present\tactual=missing\n")
+		default:
+			fmt.Fprintf(w, "  %s\texpected=%s\tactual=%s\n", d.Field, displayValue(d.A), displayValue(d.B))
+		}
+	}
+	w.Flush()
+	fmt.Fprintf(os.Stderr, "FAIL: %d difference(s) in %d candidate(s) against %s\n", len(diffs), candidates, *expectedPath)
+	return 1
+}
+
+// cmdVerifyRunner checks that main.go's RunnerTables match the rulebook's
+// tables with calculated fields; exits 1 when the generator needs to rerun
+func cmdVerifyRunner(args []string) int {
+	rb, ok := loadDefaultRulebook()
+	if !ok {
+		return 1
+	}
+
+	expected := map[string]bool{}
+	for _, t := range ExpectedTables(rb) {
+		expected[t] = true
+	}
+	stale := false
+	for _, t := range RunnerTables {
+		if !expected[t] {
+			fmt.Printf("runner processes %s, which has no calculated fields in the rulebook\n", t)
+			stale = true
+		}
+		delete(expected, t)
+	}
+	for _, t := range ExpectedTables(rb) {
+		if expected[t] {
+			fmt.Printf("runner is missing %s\n", t)
+			stale = true
+		}
+	}
+
+	if stale {
+		fmt.Println("main.go is stale: re-run inject-into-golang.py")
+		return 1
+	}
+	fmt.Printf("main.go processes the expected tables: %s\n", strings.Join(RunnerTables, ", "))
+	return 0
+}
+
+// loadResolvedCandidates loads candidates and resolves their template_id
+func loadResolvedCandidates(path string) ([]LanguageCandidate, error) {
+	records, err := LoadTemplatedCandidates(path)
+	if err != nil {
+		return nil, err
+	}
+	return ResolveTemplates(records)
+}
+
+// takeTestCSVSerializer returns a CSVSerializer for the -columns flag value,
+// defaulting to the rulebook's field order
+func takeTestCSVSerializer(columns string) (CSVSerializer, error) {
+	if columns != "" {
+		s := CSVSerializer{Columns: strings.Split(columns, ",")}
+		_, err := Project(nil, s.Columns)
+		return s, err
+	}
+	rb, err := LoadFromRulebook(DefaultRulebookPath)
+	if err != nil {
+		return CSVSerializer{}, err
+	}
+	order, err := RulebookFieldOrder(rb, "LanguageCandidates")
+	return CSVSerializer{Columns: order}, err
+}
+
+// cmdTakeTest computes LanguageCandidates once and writes every requested output format
+func cmdTakeTest(args []string) int {
+	fs := flag.NewFlagSet("take-test", flag.ContinueOnError)
+	in := fs.String("in", filepath.Join(defaultBlankTestsDir, "language_candidates.json"), "blank test to compute")
+	outJSON := fs.String("out-json", "", "write computed answers as JSON")
+	outCSV := fs.String("out-csv", "", "write computed answers as CSV")
+	outYAML := fs.String("out-yaml", "", "write computed answers as YAML")
+	outProto := fs.String("out-proto", "", "write computed answers as length-delimited erb.LanguageCandidate protobuf messages")
+	columns := fs.String("columns", "", "comma-separated json field names for -out-csv (default: rulebook schema order)")
+	boolFormat := fs.String("bool-format", "native", "booleans in -out-json and -out-csv: native, numeric (1/0) or yesno")
+	flex := fs.Bool("flex-bools", false, "accept 1/0 and \"yes\"/\"no\" style booleans in -in")
+	templates := fs.Bool("templates", false, "resolve template_id inheritance in -in before computing")
+	defaultsPath := fs.String("defaults", "", "JSON object of raw field defaults applied to nil fields before computing")
+	shuffle := fs.Bool("shuffle-input", false, "permute the loaded records before computing (determinism check)")
+	seed := fs.Uint64("seed", 1, "random seed for -shuffle-input")
+	sortBy := fs.String("sort-by", "", "json field to sort the output by (default: input order)")
+	recordTimeout := fs.Duration("record-timeout", 0, "fail when computing one record takes longer than this (0: no limit)")
+	project := fs.String("project", "", "comma-separated json field names to keep (with -out)")
+	out := fs.String("out", "", "write the -project projection as JSON")
+	check := fs.String("check", "", "golden answers file; exit 1 and print the differences when the computed answers disagree")
+	fs.BoolVar(&ForceOverwrite, "force", false, "allow writing to protected paths such as *.go")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (*project == "") != (*out == "") {
+		fmt.Fprintln(os.Stderr, "take-test: -project and -out must be given together")
+		return 2
+	}
+	if *flex && *templates {
+		fmt.Fprintln(os.Stderr, "take-test: -flex-bools and -templates cannot be combined")
+		return 2
+	}
+
+	bools, err := ParseBoolFormat(*boolFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "take-test: -bool-format: %v\n", err)
+		return 2
+	}
+
+	var csvSerializer CSVSerializer
+	if *outCSV != "" {
+		var err error
+		if csvSerializer, err = takeTestCSVSerializer(*columns); err != nil {
+			fmt.Fprintf(os.Stderr, "take-test: -columns: %v\n", err)
+			return 2
+		}
+		csvSerializer.Bools = bools
+	}
+
+	var targets []OutputTarget
+	for _, t := range []OutputTarget{{*outJSON, JSONSerializer{bools}}, {*outCSV, csvSerializer}, {*outYAML, YAMLSerializer{}}, {*outProto, ProtoSerializer{}}} {
+		if t.Path != "" {
+			targets = append(targets, t)
+		}
+	}
+	if *project != "" {
+		fields := strings.Split(*project, ",")
+		if _, err := Project(nil, fields); err != nil {
+			fmt.Fprintf(os.Stderr, "take-test: -project: %v\n", err)
+			return 2
+		}
+		targets = append(targets, OutputTarget{*out, ProjectionSerializer{fields}})
+	}
+	if len(targets) == 0 {
+		targets = []OutputTarget{{filepath.Join(defaultTestAnswersDir, "language_candidates.json"), JSONSerializer{}}}
+	}
+
+	load := LoadLanguageCandidateRecords
+	switch {
+	case *flex:
+		load = LoadCandidatesFlexible
+	case *templates:
+		load = loadResolvedCandidates
+	}
+	records, err := load(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if *defaultsPath != "" {
+		defaults, err := LoadDefaults(*defaultsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		for i := range records {
+			defaulted, err := ApplyDefaults(&records[i], defaults)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				return 1
+			}
+			if len(defaulted) > 0 {
+				fmt.Printf("  defaulted %s: %s\n", records[i].LanguageCandidateId, strings.Join(defaulted, ", "))
+			}
+		}
+	}
+	if *shuffle {
+		rng := rand.New(rand.NewPCG(*seed, *seed))
+		rng.Shuffle(len(records), func(i, j int) { records[i], records[j] = records[j], records[i] })
+	}
+	computed := make([]LanguageCandidate, 0, len(records))
+	for i := range records {
+		if *recordTimeout <= 0 {
+			computed = append(computed, *records[i].ComputeAll())
+			continue
+		}
+		record, err := ComputeWithPerRecordTimeout(&records[i], *recordTimeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		computed = append(computed, record)
+	}
+	if *sortBy != "" {
+		if err := SortCandidates(computed, *sortBy); err != nil {
+			fmt.Fprintf(os.Stderr, "take-test: -sort-by: %v\n", err)
+			return 2
+		}
+	}
+
+	if err := SaveAll(computed, targets); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	for _, t := range targets {
+		fmt.Printf("  ✓ %d records -> %s\n", len(computed), t.Path)
+	}
+
+	if *check != "" {
+		golden, err := LoadLanguageCandidateRecords(*check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		diffs := DiffTestCandidates(golden, computed)
+		for _, d := range diffs {
+			switch {
+			case d.Field != "":
+				fmt.Printf("%s.%s: expected=%s actual=%s\n", d.LanguageCandidateId, d.Field, displayValue(d.Input), displayValue(d.Computed))
+			case d.Input != nil:
+				fmt.Printf("%s: missing from the computed answers\n", d.LanguageCandidateId)
+			default:
+				fmt.Printf("%s: not in %s\n", d.LanguageCandidateId, *check)
+			}
+		}
+		if len(diffs) > 0 {
+			fmt.Fprintf(os.Stderr, "FAIL: %d difference(s) from %s\n", len(diffs), *check)
+			return 1
+		}
+		fmt.Printf("  ✓ matches %s\n", *check)
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// takeTest runs the take-test command with args plus -out-json into a temp
+// file and returns what it wrote
+func takeTest(t *testing.T, args ...string) []byte {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "answers.json")
+	if code := cmdTakeTest(append(args, "-out-json", out)); code != 0 {
+		t.Fatalf("take-test %v exited %d", args, code)
+	}
+	return readFile(t, out)
+}
+
+func TestTakeTestShuffleThenSortIsDeterministic(t *testing.T) {
+	var shuffled, sorted [][]byte
+	for _, seed := range []uint64{1, 42} {
+		s := fmt.Sprint(seed)
+		shuffled = append(shuffled, takeTest(t, "-shuffle-input", "-seed", s))
+		sorted = append(sorted, takeTest(t, "-shuffle-input", "-seed", s, "-sort-by", "language_candidate_id"))
+	}
+	if bytes.Equal(shuffled[0], shuffled[1]) {
+		t.Fatal("seeds 1 and 42 gave the same order; the test cannot tell shuffled from unshuffled input")
+	}
+	if !bytes.Equal(sorted[0], sorted[1]) {
+		t.Error("-shuffle-input with seeds 1 and 42 then -sort-by language_candidate_id gave different output")
+	}
+}
+
+func TestVerifyTestIgnoresPostProcessors(t *testing.T) {
+	expected := takeTest(t)
+	saved := postProcessors
+	t.Cleanup(func() { postProcessors = saved })
+	RegisterPostProcessor(func(view *LanguageCandidate) { view.Question = ptr("post-processed") })
+
+	if code := cmdVerifyTest([]string{"-expected", writeFile(t, "expected.json", expected)}); code != 0 {
+		t.Errorf("verify-test exited %d against take-test's answers with a post processor registered", code)
+	}
+}
@@ -0,0 +1,310 @@
+// ERB SDK - Batch Compute Helpers (hand-written)
+// ==============================================
+// Wrappers around the generated ComputeAll for processing slices of
+// LanguageCandidates.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ComputeAllProfiled computes every candidate and returns a folded-stack
+// profile ("LanguageCandidates;<field> <ns>" per line, flamegraph.pl input)
+// of the time spent in each compute step across the batch. Each record is
+// evaluated once, timing the steps as ComputeAll runs them.
+func ComputeAllProfiled(candidates []LanguageCandidate) ([]LanguageCandidate, []byte) {
+	computed := make([]LanguageCandidate, 0, len(candidates))
+	elapsed := make(map[string]time.Duration, len(LanguageCandidateCalculatedFields))
+
+	for i := range candidates {
+		result := candidates[i]
+		LanguageCandidateComputeGraph.evaluateTimed(&result, elapsed)
+		computed = append(computed, result)
+	}
+
+	stacks := make([]string, 0, len(elapsed))
+	for stack := range elapsed {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+
+	var folded bytes.Buffer
+	for _, stack := range stacks {
+		fmt.Fprintf(&folded, "LanguageCandidates;%s %d\n", stack, elapsed[stack].Nanoseconds())
+	}
+	return computed, folded.Bytes()
+}
+
+// evaluateTimed is Evaluate that adds each step's duration to elapsed[field]
+func (g *ComputeGraph[T]) evaluateTimed(record *T, elapsed map[string]time.Duration) {
+	for _, f := range g.Order {
+		start := time.Now()
+		g.steps[f](record)
+		elapsed[f] += time.Since(start)
+	}
+}
+
+// MissingRawFields returns the json names of raw fields that are null on the record
+func (tc *LanguageCandidate) MissingRawFields() []string {
+	fields := fieldsByJSONName(tc)
+	var missing []string
+	for _, name := range LanguageCandidateRawFields {
+		if isNullField(fields[name]) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// CalcFullName is CalcFullNameSep(", ")
+func (tc *LanguageCandidate) CalcFullName() string {
+	return tc.CalcFullNameSep(", ")
+}
+
+// CalcFullNameSep joins the candidate's Name and Category with sep, e.g.
+// "English, Natural Language". LanguageCandidates have no first/last name
+// pair, so these two make up the full name. A null or empty part is left
+// out along with its separator.
+func (tc *LanguageCandidate) CalcFullNameSep(sep string) string {
+	var parts []string
+	for _, part := range []*string{tc.Name, tc.Category} {
+		if s := stringVal(part); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// FieldDiff is a calculated field whose carried value disagrees with the
+// value the Go engine computes. DiffTestCandidates also sets the candidate
+// id and leaves Field empty for a record only one side has.
+type FieldDiff struct {
+	LanguageCandidateId string `json:"language_candidate_id,omitempty"`
+	Field               string `json:"field"`
+	Input               any    `json:"input"`
+	Computed            any    `json:"computed"`
+}
+
+// VerifyPrecomputed recomputes tc and compares every calculated field the
+// input already carried (non-null, with "" counting as null like
+// isNullField) with the computed value, returning the disagreements in
+// calculated field order. Blank tests yield none.
+func VerifyPrecomputed(tc LanguageCandidate) []FieldDiff {
+	input := fieldsByJSONName(&tc)
+	computed := fieldsByJSONName(tc.ComputeAll())
+	var diffs []FieldDiff
+	for _, name := range LanguageCandidateCalculatedFields {
+		if isNullField(input[name]) || input[name].Kind() == reflect.Pointer && isNullField(input[name].Elem()) {
+			continue
+		}
+		carried := derefValue(input[name])
+		if value := derefValue(computed[name]); !reflect.DeepEqual(carried, value) {
+			diffs = append(diffs, FieldDiff{Field: name, Input: carried, Computed: value})
+		}
+	}
+	return diffs
+}
+
+// DiffTestCandidates compares computed answers with expected (golden)
+// ones, matching records by language_candidate_id, and returns every field
+// whose values differ with the expected value as Input and the actual one
+// as Computed. A nil pointer differs from any non-nil one, including "".
+// A record only expected has comes back with Input set to its id, one only
+// actual has with Computed set to it.
+func DiffTestCandidates(expected, actual []LanguageCandidate) []FieldDiff {
+	byID := make(map[string]*LanguageCandidate, len(actual))
+	for i := range actual {
+		byID[actual[i].LanguageCandidateId] = &actual[i]
+	}
+
+	var diffs []FieldDiff
+	seen := make(map[string]bool, len(expected))
+	for i := range expected {
+		id := expected[i].LanguageCandidateId
+		seen[id] = true
+		a, ok := byID[id]
+		if !ok {
+			diffs = append(diffs, FieldDiff{LanguageCandidateId: id, Input: id})
+			continue
+		}
+		for _, f := range CompareCandidates(expected[i], *a) {
+			if f.Differs {
+				diffs = append(diffs, FieldDiff{LanguageCandidateId: id, Field: f.Field, Input: f.A, Computed: f.B})
+			}
+		}
+	}
+	for i := range actual {
+		if id := actual[i].LanguageCandidateId; !seen[id] {
+			diffs = append(diffs, FieldDiff{LanguageCandidateId: id, Computed: id})
+		}
+	}
+	return diffs
+}
+
+// Defaults maps raw field json names to the values nil fields take before
+// compute, e.g. {"has_identity": false, "distance_from_concept": 3}
+type Defaults map[string]any
+
+// LoadDefaults reads a Defaults config from a JSON object file
+func LoadDefaults(path string) (Defaults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read defaults: %w", err)
+	}
+
+	var defaults Defaults
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse defaults: %w", err)
+	}
+	return defaults, nil
+}
+
+// ApplyDefaults sets every nil raw field of lc that has an entry in defaults
+// and returns the json names of the fields it defaulted, in raw field order.
+// Fields that already hold a value are left alone. A default for a field
+// that is not raw, or of the wrong type, is an error and nothing is set.
+func ApplyDefaults(lc *LanguageCandidate, defaults Defaults) ([]string, error) {
+	var staged LanguageCandidate
+	stagedFields := fieldsByJSONName(&staged)
+	for name, value := range defaults {
+		if !slices.Contains(LanguageCandidateRawFields, name) {
+			return nil, fmt.Errorf("default for %s: not a raw field", name)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("default for %s: %w", name, err)
+		}
+		if err := json.Unmarshal(data, stagedFields[name].Addr().Interface()); err != nil {
+			return nil, fmt.Errorf("default for %s: %w", name, err)
+		}
+	}
+
+	fields := fieldsByJSONName(lc)
+	var defaulted []string
+	for _, name := range LanguageCandidateRawFields {
+		if _, ok := defaults[name]; !ok || !isNullField(fields[name]) {
+			continue
+		}
+		fields[name].Set(stagedFields[name])
+		defaulted = append(defaulted, name)
+	}
+	return defaulted, nil
+}
+
+// ComputeAllStrict is ComputeAll for strict mode: instead of letting nil
+// raw fields silently evaluate as false/""/0, it refuses the record
+func (tc *LanguageCandidate) ComputeAllStrict() (*LanguageCandidate, error) {
+	if missing := tc.MissingRawFields(); len(missing) > 0 {
+		return nil, fmt.Errorf("%s: missing raw fields %v", tc.LanguageCandidateId, missing)
+	}
+	return tc.ComputeAll(), nil
+}
+
+// ComputeAllWithRecovery computes every candidate in strict mode. A record
+// that fails is copied, passed to repair (e.g. to fill defaults) and retried
+// once; ids that still fail are returned and left out of the computed slice.
+func ComputeAllWithRecovery(candidates []LanguageCandidate, repair func(*LanguageCandidate)) ([]LanguageCandidate, []string) {
+	computed := make([]LanguageCandidate, 0, len(candidates))
+	var failed []string
+
+	for i := range candidates {
+		tc, err := candidates[i].ComputeAllStrict()
+		if err != nil && repair != nil {
+			repaired := candidates[i]
+			repair(&repaired)
+			tc, err = repaired.ComputeAllStrict()
+		}
+		if err != nil {
+			failed = append(failed, candidates[i].LanguageCandidateId)
+			continue
+		}
+		computed = append(computed, *tc)
+	}
+
+	return computed, failed
+}
+
+// ComputeBatch computes a mixed batch of records from any tables, preserving order
+func ComputeBatch(records []Record) []Record {
+	computed := make([]Record, len(records))
+	for i, r := range records {
+		computed[i] = r.Compute()
+	}
+	return computed
+}
+
+// PostProcessor enriches a computed candidate, e.g. with an external score.
+// Post processors are view-only: they shape what display commands (show,
+// compare, edit) and library callers of ComputeView, Views and AllViews
+// see, but never the runner's answers, which are ComputeAll output.
+type PostProcessor func(view *LanguageCandidate)
+
+// postProcessors run in registration order
+var postProcessors []PostProcessor
+
+// RegisterPostProcessor adds p to the hooks ComputeView runs after ComputeAll
+func RegisterPostProcessor(p PostProcessor) {
+	postProcessors = append(postProcessors, p)
+}
+
+// ComputeView computes a candidate and runs every registered PostProcessor on
+// the result, in registration order
+func (tc *LanguageCandidate) ComputeView() *LanguageCandidate {
+	view := tc.ComputeAll()
+	for _, p := range postProcessors {
+		p(view)
+	}
+	return view
+}
+
+// ComputeTimeoutError reports a record whose compute outlasted its timeout
+type ComputeTimeoutError struct {
+	LanguageCandidateId string
+	Timeout             time.Duration
+}
+
+func (e *ComputeTimeoutError) Error() string {
+	return fmt.Sprintf("%s: compute exceeded the %s timeout", e.LanguageCandidateId, e.Timeout)
+}
+
+// ComputeWithPerRecordTimeout computes lc (ComputeAll, like the rest of the
+// runner) and returns a *ComputeTimeoutError
+// if it takes longer than timeout. Compute cannot be interrupted, so a
+// timed-out computation keeps running on its own goroutine, on a copy of
+// lc, and its result is discarded.
+func ComputeWithPerRecordTimeout(lc *LanguageCandidate, timeout time.Duration) (LanguageCandidate, error) {
+	record := *lc
+	done := make(chan *LanguageCandidate, 1)
+	go func() { done <- record.ComputeAll() }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case computed := <-done:
+		return *computed, nil
+	case <-timer.C:
+		return LanguageCandidate{}, &ComputeTimeoutError{LanguageCandidateId: lc.LanguageCandidateId, Timeout: timeout}
+	}
+}
+
+// Views returns an iterator that computes each candidate's view (ComputeView)
+// lazily as it is ranged over, in slice order
+func Views(candidates []LanguageCandidate) iter.Seq[LanguageCandidate] {
+	return func(yield func(LanguageCandidate) bool) {
+		for i := range candidates {
+			if !yield(*candidates[i].ComputeView()) {
+				return
+			}
+		}
+	}
+}
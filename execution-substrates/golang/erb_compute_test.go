@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// countingGraph replaces LanguageCandidateComputeGraph for the test with one
+// whose steps count their calls
+func countingGraph(t *testing.T) map[string]int {
+	t.Helper()
+	orig := LanguageCandidateComputeGraph
+	calls := map[string]int{}
+	steps := make(map[string]func(*LanguageCandidate), len(orig.steps))
+	for field, step := range orig.steps {
+		steps[field] = func(tc *LanguageCandidate) {
+			calls[field]++
+			step(tc)
+		}
+	}
+	g, err := NewComputeGraph(LanguageCandidateCalculatedFields, LanguageCandidateFieldDeps, steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	LanguageCandidateComputeGraph = g
+	t.Cleanup(func() { LanguageCandidateComputeGraph = orig })
+	return calls
+}
+
+func TestComputeAllProfiledEvaluatesOnce(t *testing.T) {
+	rb := loadTestRulebook(t)
+	want := computedCandidates(t)
+
+	calls := countingGraph(t)
+	computed, folded := ComputeAllProfiled(rb.LanguageCandidates)
+
+	if !reflect.DeepEqual(computed, want) {
+		t.Error("ComputeAllProfiled results differ from ComputeAll")
+	}
+	for _, field := range LanguageCandidateCalculatedFields {
+		if calls[field] != len(rb.LanguageCandidates) {
+			t.Errorf("step %s ran %d times for %d candidates", field, calls[field], len(rb.LanguageCandidates))
+		}
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(folded)), "\n")
+	if len(lines) != len(LanguageCandidateCalculatedFields) {
+		t.Fatalf("profile has %d lines, want one per calculated field:\n%s", len(lines), folded)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "LanguageCandidates;") || strings.Count(line, " ") != 1 {
+			t.Errorf("profile line %q is not folded-stack format", line)
+		}
+	}
+}
+
+func TestPostProcessorsAreViewOnly(t *testing.T) {
+	saved := postProcessors
+	t.Cleanup(func() { postProcessors = saved })
+	RegisterPostProcessor(func(view *LanguageCandidate) { view.Question = ptr("post-processed") })
+
+	rb := loadTestRulebook(t)
+	lc := candidateByID(t, rb.LanguageCandidates, "a-coffee-mug")
+	if got := stringVal(lc.ComputeView().Question); got != "post-processed" {
+		t.Errorf("ComputeView Question = %q, want the post processor's", got)
+	}
+	if got := stringVal(lc.ComputeAll().Question); got != "Is A Coffee Mug a language?" {
+		t.Errorf("ComputeAll Question = %q", got)
+	}
+	record, err := ComputeWithPerRecordTimeout(&lc, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := stringVal(record.Question); got != "Is A Coffee Mug a language?" {
+		t.Errorf("ComputeWithPerRecordTimeout Question = %q, want ComputeAll's", got)
+	}
+}
+
+// randomNilProbability is the chance a randomCandidate leaves a raw field nil
+const randomNilProbability = 0.2
+
+// randomStrings are the values a randomCandidate picks for string fields
+var randomStrings = []string{"", "English", "A Rock", "Formal Language", "Physical Object", "Ünïcödé", "quote \" and ' mark"}
+
+// randomCandidate is a LanguageCandidate with random raw fields (each nil
+// with probability randomNilProbability) and no calculated fields
+type randomCandidate struct {
+	LanguageCandidate
+}
+
+// Generate implements quick.Generator
+func (randomCandidate) Generate(rng *rand.Rand, _ int) reflect.Value {
+	var rc randomCandidate
+	rc.LanguageCandidateId = fmt.Sprintf("random-%d", rng.Uint32())
+
+	fields := fieldsByJSONName(&rc.LanguageCandidate)
+	for _, name := range LanguageCandidateRawFields {
+		f := fields[name]
+		if f.Kind() != reflect.Pointer || rng.Float64() < randomNilProbability {
+			continue
+		}
+		v := reflect.New(f.Type().Elem())
+		switch v.Elem().Kind() {
+		case reflect.Bool:
+			v.Elem().SetBool(rng.Intn(2) == 1)
+		case reflect.Int:
+			v.Elem().SetInt(int64(rng.Intn(7) - 1))
+		case reflect.String:
+			v.Elem().SetString(randomStrings[rng.Intn(len(randomStrings))])
+		}
+		f.Set(v)
+	}
+	return reflect.ValueOf(rc)
+}
+
+// ComputeAll never panics on random candidates (a panic fails the test),
+// and computing a computed record again changes nothing
+func TestComputeAllIdempotent(t *testing.T) {
+	idempotent := func(rc randomCandidate) bool {
+		once := rc.ComputeAll()
+		twice := once.ComputeAll()
+		if patch := ComputeViewPatch(*once, *twice); len(patch) > 0 {
+			t.Logf("%s: recomputing changed %v", rc.LanguageCandidateId, patch)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(idempotent, &quick.Config{MaxCount: 1000}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestComputeAllWithRecoveryRetriesRepairedRecords(t *testing.T) {
+	rb := loadTestRulebook(t)
+	python := candidateByID(t, rb.LanguageCandidates, "python")
+	english := candidateByID(t, rb.LanguageCandidates, "english")
+	want := *python.ComputeAll()
+
+	python.HasSyntax = nil
+	english.Name = nil
+	repair := func(lc *LanguageCandidate) {
+		if lc.HasSyntax == nil {
+			lc.HasSyntax = ptr(true)
+		}
+	}
+
+	computed, failed := ComputeAllWithRecovery([]LanguageCandidate{python, english}, repair)
+	if len(failed) != 1 || failed[0] != "english" {
+		t.Errorf("failed = %v, want [english]", failed)
+	}
+	if len(computed) != 1 {
+		t.Fatalf("computed %d candidates, want 1", len(computed))
+	}
+	if diffs := DiffTestCandidates([]LanguageCandidate{want}, computed); len(diffs) > 0 {
+		t.Errorf("repaired python differs from the original: %+v", diffs)
+	}
+	if python.HasSyntax != nil {
+		t.Error("repair modified the caller's candidate")
+	}
+
+	if _, failed := ComputeAllWithRecovery([]LanguageCandidate{python}, nil); len(failed) != 1 {
+		t.Errorf("failed without a repair = %v, want [python]", failed)
+	}
+}
+
+func TestPostProcessorsRunOnEveryViewInOrder(t *testing.T) {
+	saved := postProcessors
+	t.Cleanup(func() { postProcessors = saved })
+	RegisterPostProcessor(func(view *LanguageCandidate) { view.Question = ptr("first") })
+	RegisterPostProcessor(func(view *LanguageCandidate) { view.Question = ptr(stringVal(view.Question) + " then second") })
+
+	rb := loadTestRulebook(t)
+	n := 0
+	for view := range Views(rb.LanguageCandidates) {
+		n++
+		if got := stringVal(view.Question); got != "first then second" {
+			t.Errorf("%s Question = %q, want both post processors in registration order", view.LanguageCandidateId, got)
+		}
+	}
+	if n != len(rb.LanguageCandidates) {
+		t.Errorf("Views yielded %d views, want %d", n, len(rb.LanguageCandidates))
+	}
+}
+
+func TestViewsYieldsEachViewOnceInOrder(t *testing.T) {
+	rb := loadTestRulebook(t)
+	var ids []string
+	for view := range Views(rb.LanguageCandidates) {
+		if view.Question == nil {
+			t.Errorf("%s was yielded uncomputed", view.LanguageCandidateId)
+		}
+		ids = append(ids, view.LanguageCandidateId)
+	}
+	if len(ids) != len(rb.LanguageCandidates) {
+		t.Fatalf("Views yielded %d views, want %d", len(ids), len(rb.LanguageCandidates))
+	}
+	for i, id := range ids {
+		if id != rb.LanguageCandidates[i].LanguageCandidateId {
+			t.Errorf("view %d = %s, want %s", i, id, rb.LanguageCandidates[i].LanguageCandidateId)
+		}
+	}
+
+	calls := countingGraph(t)
+	for range Views(rb.LanguageCandidates) {
+		break
+	}
+	if calls["question"] != 1 {
+		t.Errorf("breaking after the first view computed %d views, want 1", calls["question"])
+	}
+}
+
+func TestComputeWithPerRecordTimeoutFires(t *testing.T) {
+	orig := LanguageCandidateComputeGraph
+	release, finished := make(chan struct{}), make(chan struct{})
+	steps := make(map[string]func(*LanguageCandidate), len(orig.steps))
+	for field, step := range orig.steps {
+		steps[field] = step
+	}
+	question := steps["question"]
+	steps["question"] = func(tc *LanguageCandidate) {
+		defer close(finished)
+		<-release
+		question(tc)
+	}
+	g, err := NewComputeGraph(LanguageCandidateCalculatedFields, LanguageCandidateFieldDeps, steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	LanguageCandidateComputeGraph = g
+	t.Cleanup(func() { LanguageCandidateComputeGraph = orig })
+
+	lc := candidateByID(t, loadTestRulebook(t).LanguageCandidates, "python")
+	lc.Question = nil
+	_, err = ComputeWithPerRecordTimeout(&lc, 10*time.Millisecond)
+	var timeout *ComputeTimeoutError
+	if !errors.As(err, &timeout) || timeout.LanguageCandidateId != "python" || timeout.Timeout != 10*time.Millisecond {
+		t.Errorf("ComputeWithPerRecordTimeout = %v, want a *ComputeTimeoutError for python", err)
+	}
+
+	// The abandoned computation runs on to completion on its own copy
+	close(release)
+	<-finished
+	if lc.Question != nil {
+		t.Error("the timed-out computation wrote to the caller's candidate")
+	}
+}
+
+func TestCalcFullNameSep(t *testing.T) {
+	tests := []struct {
+		name, category *string
+		sep, want      string
+	}{
+		{ptr("English"), ptr("Natural Language"), " / ", "English / Natural Language"},
+		{ptr("English"), nil, " / ", "English"},
+		{nil, ptr("Natural Language"), " / ", "Natural Language"},
+		{ptr(""), ptr("Natural Language"), " / ", "Natural Language"},
+		{nil, nil, " / ", ""},
+	}
+	for _, tt := range tests {
+		lc := LanguageCandidate{Name: tt.name, Category: tt.category}
+		if got := lc.CalcFullNameSep(tt.sep); got != tt.want {
+			t.Errorf("CalcFullNameSep(%q) on %v/%v = %q, want %q", tt.sep, stringVal(tt.name), stringVal(tt.category), got, tt.want)
+		}
+	}
+
+	lc := LanguageCandidate{Name: ptr("English"), Category: ptr("Natural Language")}
+	if got := lc.CalcFullName(); got != "English, Natural Language" {
+		t.Errorf("CalcFullName() = %q, want the \", \" default", got)
+	}
+	lc.Category = nil
+	if got := lc.CalcFullName(); got != "English" {
+		t.Errorf("CalcFullName() without a category = %q, want no dangling separator", got)
+	}
+}
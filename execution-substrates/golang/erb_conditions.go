@@ -0,0 +1,409 @@
+// ERB SDK - Prediction Conditions (hand-written)
+// ==============================================
+// Helpers over the symbolic branch of the PredictedAnswer formula:
+//
+//   AND({{HasSyntax}}, {{IsParsed}}, {{IsDescriptionOf}}, {{HasLinearDecodingPressure}},
+//       {{ResolvesToAnAST}}, {{IsStableOntologyReference}}, NOT({{CanBeHeld}}), NOT({{HasIdentity}}))
+//
+// Keep PredictionConditions in sync with that formula when the rulebook changes.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PredictionCondition is one conjunct of the symbolic branch of PredictedAnswer
+type PredictionCondition struct {
+	Field string // json name of the field the condition reads
+	Want  bool   // value the field must have for the condition to hold
+	value func(tc *LanguageCandidate) *bool
+}
+
+// Holds reports whether the condition is satisfied (nil counts as false, like boolVal)
+func (c PredictionCondition) Holds(tc *LanguageCandidate) bool {
+	return boolVal(c.value(tc)) == c.Want
+}
+
+// PredictionConditions lists the eight conditions in formula order
+var PredictionConditions = []PredictionCondition{
+	{Field: "has_syntax", Want: true, value: func(tc *LanguageCandidate) *bool { return tc.HasSyntax }},
+	{Field: "is_parsed", Want: true, value: func(tc *LanguageCandidate) *bool { return tc.IsParsed }},
+	{Field: "is_description_of", Want: true, value: func(tc *LanguageCandidate) *bool {
+		// Derived from DistanceFromConcept so raw and computed records agree
+		if tc.DistanceFromConcept == nil {
+			return nil
+		}
+		v := tc.CalcIsDescriptionOf()
+		return &v
+	}},
+	{Field: "has_linear_decoding_pressure", Want: true, value: func(tc *LanguageCandidate) *bool { return tc.HasLinearDecodingPressure }},
+	{Field: "resolves_to_an_ast", Want: true, value: func(tc *LanguageCandidate) *bool { return tc.ResolvesToAnAST }},
+	{Field: "is_stable_ontology_reference", Want: true, value: func(tc *LanguageCandidate) *bool { return tc.IsStableOntologyReference }},
+	{Field: "can_be_held", Want: false, value: func(tc *LanguageCandidate) *bool { return tc.CanBeHeld }},
+	{Field: "has_identity", Want: false, value: func(tc *LanguageCandidate) *bool { return tc.HasIdentity }},
+}
+
+// PredictionScore counts how many of the eight PredictionConditions hold
+func (tc *LanguageCandidate) PredictionScore() int {
+	score := 0
+	for _, c := range PredictionConditions {
+		if c.Holds(tc) {
+			score++
+		}
+	}
+	return score
+}
+
+// FailedConditions returns the json names of the conditions that do not hold
+func (tc *LanguageCandidate) FailedConditions() []string {
+	var failed []string
+	for _, c := range PredictionConditions {
+		if !c.Holds(tc) {
+			failed = append(failed, c.Field)
+		}
+	}
+	return failed
+}
+
+// IsMismatch reports whether the predicted answer disagrees with IsLanguage
+// (the first clause of the PredictionFail formula). Works on raw or computed records.
+func (tc *LanguageCandidate) IsMismatch() bool {
+	return boolVal(tc.ComputeAll().PredictedAnswer) != boolVal(tc.IsLanguage)
+}
+
+// hockettFeatureCount is the number of traits summed by Bio_HockettScore
+const hockettFeatureCount = 11
+
+// CalcVerdictExplanation is a one-paragraph, human-readable bottom line:
+// which conditions decided PredictedAnswer and whether the candidate is
+// marked IsLanguage, e.g. "A Rock fails HasSyntax, IsParsed, so it's not a
+// top answer. It is not marked as a language." Works on raw or computed records.
+func (tc *LanguageCandidate) CalcVerdictExplanation() string {
+	computed := tc.ComputeAll()
+	name := stringVal(computed.Name)
+	if name == "" {
+		name = computed.LanguageCandidateId
+	}
+
+	// Conditions as spelled in the formula, e.g. HasSyntax or NOT(CanBeHeld)
+	var failed []string
+	for _, c := range PredictionConditions {
+		if c.Holds(computed) {
+			continue
+		}
+		field := goFieldName(LanguageCandidate{}, c.Field)
+		if !c.Want {
+			field = "NOT(" + field + ")"
+		}
+		failed = append(failed, field)
+	}
+	hockett := computed.CalcBio_HockettScore()
+
+	var sb strings.Builder
+	switch {
+	case len(failed) == 0:
+		fmt.Fprintf(&sb, "%s satisfies all %d/%d Family Feud conditions, so it's a top answer.",
+			name, computed.PredictionScore(), len(PredictionConditions))
+	case boolVal(computed.PredictedAnswer):
+		fmt.Fprintf(&sb, "%s fails %s, but shows %d/%d Hockett design features, so it's a top answer.",
+			name, strings.Join(failed, ", "), hockett, hockettFeatureCount)
+	default:
+		fmt.Fprintf(&sb, "%s fails %s, so it's not a top answer.", name, strings.Join(failed, ", "))
+	}
+
+	if boolVal(computed.IsLanguage) {
+		sb.WriteString(" It is marked as a language.")
+	} else {
+		sb.WriteString(" It is not marked as a language.")
+	}
+	return sb.String()
+}
+
+// ExplainVerdictChange says which raw-field changes between two versions
+// of a candidate flipped PredictedAnswer, e.g. "Setting has_syntax to true
+// made this a top answer." Changes that flip the verdict on their own are
+// named; when none does alone, every changed raw field is.
+func ExplainVerdictChange(before, after LanguageCandidate) string {
+	was := boolVal(before.ComputeAll().PredictedAnswer)
+	now := boolVal(after.ComputeAll().PredictedAnswer)
+	if was == now {
+		state := "a top answer"
+		if !now {
+			state = "not a top answer"
+		}
+		return "The verdict didn't change: this is still " + state + "."
+	}
+
+	beforeFields, afterFields := fieldsByJSONName(&before), fieldsByJSONName(&after)
+	var changed, decisive []string
+	for _, name := range LanguageCandidateRawFields {
+		value := afterFields[name]
+		if reflect.DeepEqual(derefValue(beforeFields[name]), derefValue(value)) {
+			continue
+		}
+		changed = append(changed, name)
+
+		single := before
+		fieldsByJSONName(&single)[name].Set(value)
+		if boolVal(single.ComputeAll().PredictedAnswer) == now {
+			decisive = append(decisive, name)
+		}
+	}
+	if len(decisive) == 0 {
+		decisive = changed
+	}
+
+	settings := make([]string, len(decisive))
+	for i, name := range decisive {
+		settings[i] = fmt.Sprintf("%s to %s", name, displayValue(derefValue(afterFields[name])))
+	}
+	outcome := "made this a top answer"
+	if !now {
+		outcome = "made this no longer a top answer"
+	}
+	return fmt.Sprintf("Setting %s %s.", strings.Join(settings, " and "), outcome)
+}
+
+// TopAnswerWithConditionsDisabled evaluates PredictedAnswer with the named
+// conditions (json names) treated as satisfied, for sensitivity analysis.
+// Names that are not PredictionConditions are ignored; with none disabled
+// the result equals CalcPredictedAnswer.
+func TopAnswerWithConditionsDisabled(lc *LanguageCandidate, disabled []string) bool {
+	skip := make(map[string]bool, len(disabled))
+	for _, name := range disabled {
+		skip[name] = true
+	}
+
+	symbolic := true
+	for _, c := range PredictionConditions {
+		if !skip[c.Field] && !c.Holds(lc) {
+			symbolic = false
+			break
+		}
+	}
+	return symbolic || lc.CalcBio_HockettScore() > 0
+}
+
+// DecisionTable is the truth table of the eight PredictionConditions: a
+// header row naming them as in the formula (json names, NOT(...) for the
+// negated ones) plus "predicted_answer", then one row of whether each
+// condition holds for each of the 256 combinations (all holding first),
+// with the PredictedAnswer the generated code computes for it. The Hockett
+// traits are left nil, so only the symbolic branch decides.
+func DecisionTable() [][]string {
+	header := make([]string, 0, len(PredictionConditions)+1)
+	for _, c := range PredictionConditions {
+		if c.Want {
+			header = append(header, c.Field)
+		} else {
+			header = append(header, "NOT("+c.Field+")")
+		}
+	}
+	table := [][]string{append(header, "predicted_answer")}
+
+	for mask := 0; mask < 1<<len(PredictionConditions); mask++ {
+		var lc LanguageCandidate
+		fields := fieldsByJSONName(&lc)
+		row := make([]string, 0, len(header)+1)
+		for i, c := range PredictionConditions {
+			holds := mask&(1<<i) == 0
+			row = append(row, strconv.FormatBool(holds))
+			v := holds == c.Want
+			if c.Field == "is_description_of" {
+				// Calculated from the distance: more than 1 step away describes the thing
+				distance := 1
+				if v {
+					distance = 2
+				}
+				lc.DistanceFromConcept = &distance
+				continue
+			}
+			fields[c.Field].Set(reflect.ValueOf(&v))
+		}
+		table = append(table, append(row, strconv.FormatBool(boolVal(lc.ComputeAll().PredictedAnswer))))
+	}
+	return table
+}
+
+// CollapseDecisionTable merges the rows of a DecisionTable into prime
+// implicants per verdict (Quine-McCluskey), writing "-" for don't-care
+// conditions. For the AND of eight conditions this is one true row and
+// eight false rows, one per failing condition. Rows are sorted with true
+// verdicts first.
+func CollapseDecisionTable(table [][]string) [][]string {
+	if len(table) == 0 {
+		return nil
+	}
+	width := len(table[0]) - 1
+
+	var collapsed [][]string
+	for _, verdict := range []string{"true", "false"} {
+		cubes := map[string][]string{}
+		for _, row := range table[1:] {
+			if row[width] == verdict {
+				cubes[strings.Join(row[:width], ",")] = row[:width]
+			}
+		}
+
+		for len(cubes) > 0 {
+			next := map[string][]string{}
+			merged := map[string]bool{}
+			for ka, a := range cubes {
+				for kb, b := range cubes {
+					if ka >= kb {
+						continue
+					}
+					if c, ok := mergeCubes(a, b); ok {
+						next[strings.Join(c, ",")] = c
+						merged[ka], merged[kb] = true, true
+					}
+				}
+			}
+			for k, c := range cubes {
+				if !merged[k] {
+					collapsed = append(collapsed, append(slices.Clone(c), verdict))
+				}
+			}
+			cubes = next
+		}
+	}
+
+	sort.SliceStable(collapsed, func(i, j int) bool {
+		if collapsed[i][width] != collapsed[j][width] {
+			return collapsed[i][width] == "true"
+		}
+		return strings.Join(collapsed[i], ",") > strings.Join(collapsed[j], ",")
+	})
+	return append([][]string{table[0]}, collapsed...)
+}
+
+// mergeCubes combines two rows that differ in exactly one specified
+// condition into one with "-" there
+func mergeCubes(a, b []string) ([]string, bool) {
+	diff := -1
+	for i := range a {
+		if a[i] == b[i] {
+			continue
+		}
+		if a[i] == "-" || b[i] == "-" || diff >= 0 {
+			return nil, false
+		}
+		diff = i
+	}
+	if diff < 0 {
+		return nil, false
+	}
+	c := slices.Clone(a)
+	c[diff] = "-"
+	return c, true
+}
+
+// =============================================================================
+// THREE-VALUED (STRICT NULL) EVALUATION
+// =============================================================================
+
+// not3 is SQL NOT: NOT NULL is NULL
+func not3(b *bool) *bool {
+	if b == nil {
+		return nil
+	}
+	v := !*b
+	return &v
+}
+
+// and3 is SQL AND: any FALSE wins, otherwise any NULL makes the result NULL
+func and3(values ...*bool) *bool {
+	unknown := false
+	for _, b := range values {
+		if b == nil {
+			unknown = true
+		} else if !*b {
+			f := false
+			return &f
+		}
+	}
+	if unknown {
+		return nil
+	}
+	t := true
+	return &t
+}
+
+// or3 is SQL OR: any TRUE wins, otherwise any NULL makes the result NULL
+func or3(values ...*bool) *bool {
+	unknown := false
+	for _, b := range values {
+		if b == nil {
+			unknown = true
+		} else if *b {
+			t := true
+			return &t
+		}
+	}
+	if unknown {
+		return nil
+	}
+	f := false
+	return &f
+}
+
+// StrictNullReport summarizes how PredictedAnswer depends on nil-as-false
+type StrictNullReport struct {
+	Total        int      `json:"total"`
+	Changed      []string `json:"changed"`      // strict verdict known but different from lenient
+	Undetermined []string `json:"undetermined"` // strict verdict unknown
+	// UndeterminedTrue counts undetermined candidates that are lenient top answers
+	UndeterminedTrue int `json:"undetermined_true"`
+}
+
+// StrictNullImpact computes PredictedAnswer leniently (nil=false, as the
+// generated code does) and strictly (CalcPredictedAnswerThreeValued) for
+// every candidate in the rulebook and reports the ones whose verdict
+// depends on missing data
+func StrictNullImpact(rb *Rulebook) StrictNullReport {
+	report := StrictNullReport{Total: len(rb.LanguageCandidates)}
+	for i := range rb.LanguageCandidates {
+		tc := &rb.LanguageCandidates[i]
+		lenient := boolVal(tc.ComputeAll().PredictedAnswer)
+		strict := tc.CalcPredictedAnswerThreeValued()
+
+		switch {
+		case strict == nil:
+			report.Undetermined = append(report.Undetermined, tc.LanguageCandidateId)
+			if lenient {
+				report.UndeterminedTrue++
+			}
+		case *strict != lenient:
+			report.Changed = append(report.Changed, tc.LanguageCandidateId)
+		}
+	}
+	return report
+}
+
+// CalcPredictedAnswerThreeValued evaluates the PredictedAnswer formula with
+// plain SQL three-valued logic, and is the one strict (nil=unknown) reading
+// of it: NULL inputs to the AND branch make it NULL unless some condition
+// is definitely false, and the result is nil when the answer is genuinely
+// unknown. The Hockett branch never goes NULL, since IF(NULL, 1, 0) is 0
+// in SQL as well, so a nil trait counts as absent there.
+//
+// Note the postgres substrate wraps each raw boolean in COALESCE(..., FALSE),
+// so its calc_language_candidates_predicted_answer agrees with the lenient
+// CalcPredictedAnswer, not with this function.
+func (tc *LanguageCandidate) CalcPredictedAnswerThreeValued() *bool {
+	conditions := make([]*bool, len(PredictionConditions))
+	for i, c := range PredictionConditions {
+		conditions[i] = c.value(tc)
+		if !c.Want {
+			conditions[i] = not3(conditions[i])
+		}
+	}
+	hockett := tc.CalcBio_HockettScore() > 0
+	return or3(and3(conditions...), &hockett)
+}
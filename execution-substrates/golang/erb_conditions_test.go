@@ -0,0 +1,277 @@
+package main
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// topAnswer returns a candidate satisfying all eight PredictionConditions
+// with every Hockett trait false
+func topAnswer() LanguageCandidate {
+	f := ptr(false)
+	return LanguageCandidate{
+		LanguageCandidateId:          "top",
+		HasSyntax:                    ptr(true),
+		IsParsed:                     ptr(true),
+		DistanceFromConcept:          ptr(2),
+		HasLinearDecodingPressure:    ptr(true),
+		ResolvesToAnAST:              ptr(true),
+		IsStableOntologyReference:    ptr(true),
+		CanBeHeld:                    ptr(false),
+		HasIdentity:                  ptr(false),
+		Bio_HasSemanticity:           f,
+		Bio_HasArbitrariness:         f,
+		Bio_HasDiscreteness:          f,
+		Bio_HasDualityOfPatterning:   f,
+		Bio_HasProductivity:          f,
+		Bio_HasDisplacement:          f,
+		Bio_HasCulturalTransmission:  f,
+		Bio_HasInterchangeability:    f,
+		Bio_HasFeedback:              f,
+		Bio_HasBroadcastTransmission: f,
+		Bio_HasRapidFading:           f,
+	}
+}
+
+func TestCalcPredictedAnswerThreeValued(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(tc *LanguageCandidate)
+		want *bool
+	}{
+		{"all conditions hold", func(tc *LanguageCandidate) {}, ptr(true)},
+		{"one condition null", func(tc *LanguageCandidate) { tc.IsParsed = nil }, nil},
+		{"null distance", func(tc *LanguageCandidate) { tc.DistanceFromConcept = nil }, nil},
+		{"null beside a false condition", func(tc *LanguageCandidate) { tc.IsParsed = nil; tc.HasIdentity = ptr(true) }, ptr(false)},
+		{"null Hockett traits count as absent", func(tc *LanguageCandidate) {
+			tc.HasSyntax = ptr(false)
+			tc.Bio_HasSemanticity, tc.Bio_HasFeedback = nil, nil
+		}, ptr(false)},
+		{"Hockett trait decides a null AND", func(tc *LanguageCandidate) {
+			tc.IsParsed = nil
+			tc.Bio_HasFeedback = ptr(true)
+		}, ptr(true)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := topAnswer()
+			tt.edit(&tc)
+			got := tc.CalcPredictedAnswerThreeValued()
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("CalcPredictedAnswerThreeValued() = %s, want %s", show3(got), show3(tt.want))
+			}
+		})
+	}
+}
+
+func TestStrictNullImpactUsesThreeValuedRule(t *testing.T) {
+	nullParse := topAnswer()
+	nullParse.LanguageCandidateId = "null-parse"
+	nullParse.IsParsed = nil
+
+	nullTraits := topAnswer()
+	nullTraits.LanguageCandidateId = "null-traits"
+	nullTraits.HasSyntax = ptr(false)
+	nullTraits.Bio_HasSemanticity = nil
+
+	report := StrictNullImpact(&Rulebook{LanguageCandidates: []LanguageCandidate{topAnswer(), nullParse, nullTraits}})
+	if report.Total != 3 {
+		t.Errorf("Total = %d, want 3", report.Total)
+	}
+	if !slices.Equal(report.Undetermined, []string{"null-parse"}) {
+		t.Errorf("Undetermined = %v, want [null-parse]", report.Undetermined)
+	}
+	if len(report.Changed) != 0 || report.UndeterminedTrue != 0 {
+		t.Errorf("Changed = %v, UndeterminedTrue = %d, want none", report.Changed, report.UndeterminedTrue)
+	}
+}
+
+// show3 renders a three-valued result as true, false or NULL
+func show3(b *bool) string {
+	if b == nil {
+		return "NULL"
+	}
+	return strconv.FormatBool(*b)
+}
+
+// predictedAnswerReads returns the raw boolean fields (json names) that
+// predicted_answer reads, directly or through calculated fields
+func predictedAnswerReads() []string {
+	read := map[string]bool{}
+	queue := slices.Clone(LanguageCandidateFieldDeps["predicted_answer"])
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if !read[name] {
+			read[name] = true
+			queue = append(queue, LanguageCandidateFieldDeps[name]...)
+		}
+	}
+
+	var fields []string
+	var lc LanguageCandidate
+	byName := fieldsByJSONName(&lc)
+	for _, name := range LanguageCandidateRawFields {
+		if read[name] && byName[name].Type() == reflect.TypeOf((*bool)(nil)) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// TestTopAnswerNilFalseEquivalence pins the nil-as-false semantics of
+// PredictedAnswer: each raw boolean it reads is set on a copy of each base
+// candidate to nil, false and true in turn. Nil and false must give the
+// same answer. Where the base's other conditions already decide the outcome
+// (every other PredictionCondition holds and the Hockett branch is off), a
+// condition field's wanted value must give true and the other value false.
+// TopAnswerWithConditionsDisabled with nothing disabled must agree throughout.
+func TestTopAnswerNilFalseEquivalence(t *testing.T) {
+	bases := append(loadTestRulebook(t).LanguageCandidates, topAnswer())
+	fields := predictedAnswerReads()
+	if len(fields) == 0 {
+		t.Fatal("predicted_answer reads no raw boolean fields")
+	}
+
+	for _, base := range bases {
+		for _, name := range fields {
+			answer := func(v *bool) (bool, LanguageCandidate) {
+				lc := base
+				fieldsByJSONName(&lc)[name].Set(reflect.ValueOf(v))
+				got := boolVal(lc.ComputeAll().PredictedAnswer)
+				if disabled := TopAnswerWithConditionsDisabled(&lc, nil); disabled != got {
+					t.Errorf("%s with %s=%s: TopAnswerWithConditionsDisabled = %t, PredictedAnswer = %t", base.LanguageCandidateId, name, show3(v), disabled, got)
+				}
+				return got, lc
+			}
+			nilAnswer, _ := answer(nil)
+			falseAnswer, _ := answer(ptr(false))
+			trueAnswer, withTrue := answer(ptr(true))
+			if nilAnswer != falseAnswer {
+				t.Errorf("%s: %s nil gives predicted_answer %t but false gives %t", base.LanguageCandidateId, name, nilAnswer, falseAnswer)
+			}
+
+			i := slices.IndexFunc(PredictionConditions, func(c PredictionCondition) bool { return c.Field == name })
+			if i < 0 || withTrue.CalcBio_HockettScore() > 0 {
+				continue
+			}
+			want := PredictionConditions[i].Want
+			wanted, unwanted := trueAnswer, falseAnswer
+			if !want {
+				wanted, unwanted = falseAnswer, trueAnswer
+			}
+			others := 0
+			for _, c := range PredictionConditions {
+				if c.Field != name && c.Holds(&withTrue) {
+					others++
+				}
+			}
+			if others == len(PredictionConditions)-1 && (!wanted || unwanted) {
+				t.Errorf("%s: %s=%t should decide predicted_answer, but gives %t (and %t for %t)", base.LanguageCandidateId, name, want, wanted, unwanted, !want)
+			}
+		}
+	}
+}
+
+func TestTopAnswerWithConditionsDisabled(t *testing.T) {
+	held := topAnswer()
+	held.CanBeHeld = ptr(true)
+	if TopAnswerWithConditionsDisabled(&held, nil) {
+		t.Fatal("a holdable candidate is a top answer with nothing disabled")
+	}
+	if !TopAnswerWithConditionsDisabled(&held, []string{"can_be_held"}) {
+		t.Error("disabling can_be_held did not make the candidate a top answer")
+	}
+	if TopAnswerWithConditionsDisabled(&held, []string{"has_syntax", "not_a_condition"}) {
+		t.Error("disabling other conditions made the candidate a top answer")
+	}
+
+	mug := candidateByID(t, computedCandidates(t), "a-coffee-mug")
+	failed := mug.FailedConditions()
+	if !TopAnswerWithConditionsDisabled(&mug, failed) {
+		t.Errorf("a-coffee-mug with %v disabled is not a top answer", failed)
+	}
+	if len(failed) > 1 && TopAnswerWithConditionsDisabled(&mug, failed[1:]) {
+		t.Errorf("a-coffee-mug with %v disabled is a top answer", failed[1:])
+	}
+}
+
+func TestCalcVerdictExplanation(t *testing.T) {
+	rb := loadTestRulebook(t)
+	tests := []struct {
+		id, want string
+	}{
+		{"python", "Python satisfies all 8/8 Family Feud conditions, so it's a top answer. It is marked as a language."},
+		{"falsifier-a", "Falsifier A fails HasSyntax, IsDescriptionOf, HasLinearDecodingPressure, NOT(CanBeHeld), NOT(HasIdentity), so it's not a top answer. It is marked as a language."},
+		{"honeybee-waggle-dance", "Honeybee Waggle Dance fails HasSyntax, IsParsed, IsDescriptionOf, HasLinearDecodingPressure, ResolvesToAnAST, IsStableOntologyReference, but shows 2/11 Hockett design features, so it's a top answer. It is marked as a language."},
+	}
+	for _, tt := range tests {
+		lc := candidateByID(t, rb.LanguageCandidates, tt.id)
+		if got := lc.CalcVerdictExplanation(); got != tt.want {
+			t.Errorf("%s: CalcVerdictExplanation =\n%q\nwant\n%q", tt.id, got, tt.want)
+		}
+	}
+
+	lc := nilNameMismatch()
+	if got := lc.CalcVerdictExplanation(); !strings.HasPrefix(got, "nil-name-probe fails ") {
+		t.Errorf("nil Name: CalcVerdictExplanation = %q, want it to start with the id", got)
+	}
+}
+
+func TestExplainVerdictChange(t *testing.T) {
+	held := topAnswer()
+	held.CanBeHeld = ptr(true)
+	top := topAnswer()
+	renamed := topAnswer()
+	renamed.Name = ptr("Renamed")
+	python := candidateByID(t, loadTestRulebook(t).LanguageCandidates, "python")
+	noSyntax := python
+	noSyntax.HasSyntax, noSyntax.Name = ptr(false), ptr("Python 2")
+
+	tests := []struct {
+		name          string
+		before, after LanguageCandidate
+		want          string
+	}{
+		{"flips to top answer", held, top, "Setting can_be_held to false made this a top answer."},
+		{"flips away, ignoring the rename", python, noSyntax, "Setting has_syntax to false made this no longer a top answer."},
+		{"no change", top, renamed, "The verdict didn't change: this is still a top answer."},
+	}
+	for _, tt := range tests {
+		if got := ExplainVerdictChange(tt.before, tt.after); got != tt.want {
+			t.Errorf("%s: ExplainVerdictChange = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecisionTable(t *testing.T) {
+	table := DecisionTable()
+	if len(table) != 1+256 {
+		t.Fatalf("DecisionTable has %d rows, want a header and 256", len(table))
+	}
+	allTrue := table[1]
+	if allTrue[len(allTrue)-1] != "true" || slices.Contains(allTrue[:len(allTrue)-1], "false") {
+		t.Errorf("all-true row = %v, want every condition and the verdict true", allTrue)
+	}
+	for _, row := range table[2:] {
+		if row[len(row)-1] != "false" {
+			t.Errorf("row %v with a failing condition yields true", row)
+		}
+	}
+
+	collapsed := CollapseDecisionTable(table)
+	if len(collapsed) != 1+1+len(PredictionConditions) {
+		t.Fatalf("collapsed table has %d rows, want a header, one true row and %d false rows", len(collapsed), len(PredictionConditions))
+	}
+	if got := strings.Join(collapsed[1], ","); got != strings.Join(allTrue, ",") {
+		t.Errorf("collapsed true row = %s, want %s", got, strings.Join(allTrue, ","))
+	}
+	for _, row := range collapsed[2:] {
+		if row[len(row)-1] != "false" || strings.Count(strings.Join(row, ","), "-") != len(PredictionConditions)-1 {
+			t.Errorf("collapsed false row %v, want one failing condition and the rest don't-cares", row)
+		}
+	}
+}
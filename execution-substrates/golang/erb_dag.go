@@ -0,0 +1,334 @@
+// ERB SDK - Calculated Field Dependencies (hand-written)
+// ======================================================
+// Runtime helpers over the generated <Struct>FieldDeps maps.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// dependents inverts a FieldDeps map: field -> calculated fields that read it
+func dependents(deps map[string][]string) map[string][]string {
+	reverse := map[string][]string{}
+	for calc, refs := range deps {
+		for _, ref := range refs {
+			reverse[ref] = append(reverse[ref], calc)
+		}
+	}
+	for _, calcs := range reverse {
+		sort.Strings(calcs)
+	}
+	return reverse
+}
+
+// evaluationOrder returns the given calculated fields in the order
+// LanguageCandidateComputeGraph evaluates them
+func evaluationOrder(fields []string) []string {
+	var ordered []string
+	for _, f := range LanguageCandidateComputeGraph.Order {
+		if slices.Contains(fields, f) {
+			ordered = append(ordered, f)
+		}
+	}
+	return ordered
+}
+
+// ResolveDAG returns the calculated fields among fields in an evaluation
+// order, each after the calculated fields its formula references through
+// {{Field}} tokens (ties keep schema order). A circular reference is an
+// error naming the cycle, e.g. "A -> B -> A".
+func ResolveDAG(fields []FieldDef) ([]string, error) {
+	var order []string
+	deps := map[string][]string{}
+	steps := map[string]func(*struct{}){}
+	for _, f := range fields {
+		if f.Type != "calculated" {
+			continue
+		}
+		compiled, err := CompileFormula(f.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		deps[f.Name] = compiled.Fields
+		steps[f.Name] = func(*struct{}) {}
+		order = append(order, f.Name)
+	}
+
+	// Only the graph's order is used; its steps are placeholders
+	graph, err := NewComputeGraph(order, deps, steps)
+	if err != nil {
+		if cycle := findCycle(order, deps); cycle != nil {
+			return nil, fmt.Errorf("calculated fields form a dependency cycle: %s", strings.Join(cycle, " -> "))
+		}
+		return nil, err
+	}
+	return graph.Order, nil
+}
+
+// findCycle returns a dependency cycle among the given fields as a path
+// that starts and ends with the same field, or nil when there is none
+func findCycle(fields []string, deps map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(f string) []string
+	visit = func(f string) []string {
+		state[f] = visiting
+		path = append(path, f)
+		for _, d := range deps[f] {
+			if _, calculated := deps[d]; !calculated {
+				continue
+			}
+			switch state[d] {
+			case visiting:
+				start := slices.Index(path, d)
+				return append(slices.Clone(path[start:]), d)
+			case unvisited:
+				if cycle := visit(d); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[f] = done
+		return nil
+	}
+	for _, f := range fields {
+		if state[f] == unvisited {
+			if cycle := visit(f); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// DependencyGraph is one table's field dependency DAG: its raw fields,
+// its calculated fields in evaluation order, and what each calculated
+// field's formula reads
+type DependencyGraph struct {
+	Table      string
+	Raw        []string
+	Calculated []string
+	Deps       map[string][]string
+}
+
+// LanguageCandidateDependencyGraph returns the DAG the generated
+// LanguageCandidateComputeGraph evaluates
+func LanguageCandidateDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{
+		Table:      "LanguageCandidates",
+		Raw:        LanguageCandidateRawFields,
+		Calculated: LanguageCandidateComputeGraph.Order,
+		Deps:       LanguageCandidateFieldDeps,
+	}
+}
+
+// dagNode and dagEdge are DependencyGraph's JSON form
+type dagNode struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"` // "raw" or "calculated"
+}
+
+type dagEdge struct {
+	From string `json:"from"` // the calculated field
+	To   string `json:"to"`   // a field its formula reads
+}
+
+// MarshalJSON encodes g as {"table", "nodes", "edges"} for external tools:
+// raw nodes in schema order, then calculated nodes in evaluation order,
+// so the node order is itself a valid evaluation order
+func (g *DependencyGraph) MarshalJSON() ([]byte, error) {
+	nodes := make([]dagNode, 0, len(g.Raw)+len(g.Calculated))
+	for _, name := range g.Raw {
+		nodes = append(nodes, dagNode{name, "raw"})
+	}
+	edges := []dagEdge{}
+	for _, name := range g.Calculated {
+		nodes = append(nodes, dagNode{name, "calculated"})
+		for _, dep := range g.Deps[name] {
+			edges = append(edges, dagEdge{name, dep})
+		}
+	}
+	return json.Marshal(struct {
+		Table string    `json:"table"`
+		Nodes []dagNode `json:"nodes"`
+		Edges []dagEdge `json:"edges"`
+	}{g.Table, nodes, edges})
+}
+
+// RecomputeAffected recomputes on lc the calculated fields affected by a
+// change to the given raw fields, following dependencies transitively
+// (e.g. distance_from_concept -> is_description_of -> predicted_answer ->
+// prediction_fail), and returns their names in evaluation order. Every
+// other field is left untouched.
+func RecomputeAffected(lc *LanguageCandidate, changedRawFields []string) []string {
+	fields := affectedFields(changedRawFields)
+	lc.RecomputeFields(fields)
+	return fields
+}
+
+// affectedFields returns the calculated fields that depend, transitively, on
+// the given raw fields, in evaluation order. The dependency graph is per
+// table, so the answer is the same for every record.
+func affectedFields(changedRawFields []string) []string {
+	reverse := dependents(LanguageCandidateFieldDeps)
+	affected := map[string]bool{}
+	queue := append([]string(nil), changedRawFields...)
+	for len(queue) > 0 {
+		field := queue[0]
+		queue = queue[1:]
+		for _, calc := range reverse[field] {
+			if !affected[calc] {
+				affected[calc] = true
+				queue = append(queue, calc)
+			}
+		}
+	}
+
+	fields := make([]string, 0, len(affected))
+	for f := range affected {
+		fields = append(fields, f)
+	}
+	return evaluationOrder(fields)
+}
+
+// RecomputeFields recomputes only the named calculated fields in place, in
+// dependency order, leaving every other field untouched
+func (tc *LanguageCandidate) RecomputeFields(fields []string) {
+	for _, name := range evaluationOrder(fields) {
+		setCalculated(tc, name, LanguageCandidateCalcFuncs[name](tc))
+	}
+}
+
+// Calc computes one calculated field by json name (e.g. "prediction_fail"),
+// first computing the calculated fields it depends on, transitively. It
+// works on a copy, so lc is left untouched. The value is the Calc* method's
+// return value.
+func (lc *LanguageCandidate) Calc(field string) (any, error) {
+	calc, ok := LanguageCandidateCalcFuncs[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown calculated field %q (valid: %s)", field, strings.Join(LanguageCandidateCalculatedFields, ", "))
+	}
+
+	needed := map[string]bool{}
+	queue := slices.Clone(LanguageCandidateFieldDeps[field])
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+		if _, isCalc := LanguageCandidateCalcFuncs[dep]; isCalc && !needed[dep] {
+			needed[dep] = true
+			queue = append(queue, LanguageCandidateFieldDeps[dep]...)
+		}
+	}
+	prereqs := make([]string, 0, len(needed))
+	for f := range needed {
+		prereqs = append(prereqs, f)
+	}
+
+	c := *lc
+	c.RecomputeFields(prereqs)
+	return calc(&c), nil
+}
+
+// FieldChangeEvent sets one raw field (json name) to a new value; Value is
+// anything that JSON-encodes to the field's type, and nil clears the field
+type FieldChangeEvent struct {
+	Field string `json:"field"`
+	Value any    `json:"value"`
+}
+
+// ApplyEvent applies a raw-field change to lc, recomputes it, and returns the
+// computed view plus the calculated fields whose values changed as a result,
+// in evaluation order
+func ApplyEvent(lc *LanguageCandidate, event FieldChangeEvent) (LanguageCandidate, []string, error) {
+	if !slices.Contains(LanguageCandidateRawFields, event.Field) {
+		return LanguageCandidate{}, nil, fmt.Errorf("%q is not a raw field", event.Field)
+	}
+	before := *lc.ComputeAll()
+
+	data, err := json.Marshal(event.Value)
+	if err != nil {
+		return LanguageCandidate{}, nil, fmt.Errorf("field %s: %w", event.Field, err)
+	}
+	field := fieldsByJSONName(lc)[event.Field]
+	updated := reflect.New(field.Type())
+	if err := json.Unmarshal(data, updated.Interface()); err != nil {
+		return LanguageCandidate{}, nil, fmt.Errorf("field %s: %w", event.Field, err)
+	}
+	field.Set(updated.Elem())
+
+	after := *lc.ComputeAll()
+	patch := ComputeViewPatch(before, after)
+	var changed []string
+	for _, name := range affectedFields([]string{event.Field}) {
+		if _, ok := patch[name]; ok {
+			changed = append(changed, name)
+		}
+	}
+	return after, changed, nil
+}
+
+// EditSession edits one candidate's raw fields with undo and redo, for
+// editing UIs built on the calc engine. Views are computed on demand.
+type EditSession struct {
+	candidate  LanguageCandidate
+	undo, redo []LanguageCandidate // earlier and undone states, most recent last
+}
+
+// NewEditSession starts a session on a copy of lc
+func NewEditSession(lc LanguageCandidate) *EditSession {
+	return &EditSession{candidate: lc}
+}
+
+// Set changes one raw field (json name) as ApplyEvent does and records the
+// edit; it clears the redo history. A failed Set leaves the session as it was.
+func (s *EditSession) Set(field string, value any) error {
+	// ApplyEvent swaps in a new value rather than writing through the old
+	// pointer, so the saved copy keeps the previous value
+	edited := s.candidate
+	if _, _, err := ApplyEvent(&edited, FieldChangeEvent{Field: field, Value: value}); err != nil {
+		return err
+	}
+	s.undo = append(s.undo, s.candidate)
+	s.redo = nil
+	s.candidate = edited
+	return nil
+}
+
+// Undo reverts the last edit, reporting false when there is none
+func (s *EditSession) Undo() bool {
+	if len(s.undo) == 0 {
+		return false
+	}
+	s.redo = append(s.redo, s.candidate)
+	s.candidate = s.undo[len(s.undo)-1]
+	s.undo = s.undo[:len(s.undo)-1]
+	return true
+}
+
+// Redo reapplies the last undone edit, reporting false when there is none
+func (s *EditSession) Redo() bool {
+	if len(s.redo) == 0 {
+		return false
+	}
+	s.undo = append(s.undo, s.candidate)
+	s.candidate = s.redo[len(s.redo)-1]
+	s.redo = s.redo[:len(s.redo)-1]
+	return true
+}
+
+// View computes the current state's view (ComputeView)
+func (s *EditSession) View() LanguageCandidate {
+	return *s.candidate.ComputeView()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestAffectedFields(t *testing.T) {
+	affected := affectedFields([]string{"distance_from_concept"})
+	for _, want := range []string{"is_description_of", "predicted_answer", "prediction_fail"} {
+		if !slices.Contains(affected, want) {
+			t.Errorf("affectedFields(distance_from_concept) = %v, missing %s", affected, want)
+		}
+	}
+	if slices.Contains(affected, "question") {
+		t.Errorf("affectedFields(distance_from_concept) = %v, includes question", affected)
+	}
+	order := LanguageCandidateComputeGraph.Order
+	if !slices.IsSortedFunc(affected, func(a, b string) int { return slices.Index(order, a) - slices.Index(order, b) }) {
+		t.Errorf("affectedFields = %v, not in evaluation order %v", affected, order)
+	}
+}
+
+func TestRecomputeAffected(t *testing.T) {
+	// Recomputing only the affected fields gives the same record as ComputeAll
+	for _, lc := range computedCandidates(t) {
+		lc.DistanceFromConcept = ptr(5)
+		partial := lc
+		recomputed := RecomputeAffected(&partial, []string{"distance_from_concept"})
+		if want := affectedFields([]string{"distance_from_concept"}); !slices.Equal(recomputed, want) {
+			t.Errorf("%s: RecomputeAffected returned %v, want %v", lc.LanguageCandidateId, recomputed, want)
+		}
+		if full := lc.ComputeAll(); !reflect.DeepEqual(&partial, full) {
+			t.Errorf("%s: RecomputeAffected differs from ComputeAll: %v", lc.LanguageCandidateId, ComputeViewPatch(partial, *full))
+		}
+	}
+
+	// Fields outside the affected set keep their (stale) values
+	python := candidateByID(t, computedCandidates(t), "python")
+	python.Question = ptr("stale")
+	RecomputeAffected(&python, []string{"distance_from_concept"})
+	if python.Question == nil || *python.Question != "stale" {
+		t.Errorf("RecomputeAffected rewrote question: %v", python.Question)
+	}
+}
+
+func TestApplyEventHasSyntax(t *testing.T) {
+	rb := loadTestRulebook(t)
+	python := candidateByID(t, rb.LanguageCandidates, "python")
+
+	view, changed, err := ApplyEvent(&python, FieldChangeEvent{Field: "has_syntax", Value: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if python.HasSyntax == nil || *python.HasSyntax {
+		t.Error("ApplyEvent did not set has_syntax on the candidate")
+	}
+	want := []string{"has_grammar", "predicted_answer", "prediction_predicates", "prediction_fail"}
+	if !slices.Equal(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	if boolVal(view.PredictedAnswer) || boolVal(view.HasGrammar) {
+		t.Errorf("view predicted_answer=%s has_grammar=%s, want false", show3(view.PredictedAnswer), show3(view.HasGrammar))
+	}
+
+	if _, changed, _ := ApplyEvent(&python, FieldChangeEvent{Field: "has_syntax", Value: false}); len(changed) != 0 {
+		t.Errorf("reapplying the same value changed %v", changed)
+	}
+	if _, _, err := ApplyEvent(&python, FieldChangeEvent{Field: "predicted_answer", Value: true}); err == nil {
+		t.Error("ApplyEvent on a calculated field = nil, want an error")
+	}
+}
+
+func TestEditSessionUndoRedo(t *testing.T) {
+	python := candidateByID(t, loadTestRulebook(t).LanguageCandidates, "python")
+	s := NewEditSession(python)
+	state := func() string {
+		v := s.View()
+		return fmt.Sprintf("can_be_held=%s has_syntax=%s predicted=%s", show3(v.CanBeHeld), show3(v.HasSyntax), show3(v.PredictedAnswer))
+	}
+	original := state()
+
+	if err := s.Set("can_be_held", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("has_syntax", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("predicted_answer", true); err == nil {
+		t.Error("Set of a calculated field = nil, want an error")
+	}
+	edited := state()
+	if edited != "can_be_held=true has_syntax=false predicted=false" {
+		t.Errorf("after two edits: %s", edited)
+	}
+
+	if !s.Undo() || state() != "can_be_held=true has_syntax=true predicted=false" {
+		t.Errorf("after one undo: %s", state())
+	}
+	if !s.Undo() || state() != original {
+		t.Errorf("after two undos: %s, want %s", state(), original)
+	}
+	if s.Undo() {
+		t.Error("Undo past the first edit = true")
+	}
+	if !s.Redo() || !s.Redo() || state() != edited {
+		t.Errorf("after two redos: %s, want %s", state(), edited)
+	}
+	if s.Redo() {
+		t.Error("Redo with nothing undone = true")
+	}
+
+	// A new edit clears the redo history
+	s.Undo()
+	if err := s.Set("is_parsed", false); err != nil {
+		t.Fatal(err)
+	}
+	if s.Redo() {
+		t.Error("Redo after a new edit = true")
+	}
+	if python.CanBeHeld == nil || *python.CanBeHeld {
+		t.Error("the session modified the candidate it was started from")
+	}
+}
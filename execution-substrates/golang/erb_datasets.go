@@ -0,0 +1,129 @@
+// ERB SDK - Dataset Registry (hand-written)
+// =========================================
+// Named datasets, each a loader, a computer and a saver over generated
+// records, so one runner can process any subset of tables or rulebooks.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Dataset is one named unit of work for a DatasetRegistry. Compute may be
+// nil, in which case records are computed with ComputeBatch.
+type Dataset struct {
+	Load    func() ([]Record, error)
+	Compute func(records []Record) ([]Record, error)
+	Save    func(records []Record) error
+}
+
+// TableDataset returns a Dataset that loads a JSON array of T from
+// inputPath and saves the computed records to outputPath
+func TableDataset[T any, PT interface {
+	*T
+	Record
+}](inputPath, outputPath string) Dataset {
+	return Dataset{
+		Load: func() ([]Record, error) {
+			loaded, err := LoadRecords[T](inputPath)
+			if err != nil {
+				return nil, err
+			}
+			records := make([]Record, len(loaded))
+			for i := range loaded {
+				records[i] = PT(&loaded[i])
+			}
+			return records, nil
+		},
+		Save: func(records []Record) error {
+			out := make([]T, len(records))
+			for i, r := range records {
+				p, ok := r.(PT)
+				if !ok {
+					return fmt.Errorf("record %d is a %T, not a %T", i, r, PT(nil))
+				}
+				out[i] = *p
+			}
+			return SaveRecords(outputPath, out)
+		},
+	}
+}
+
+// DatasetRegistry runs registered datasets by name
+type DatasetRegistry struct {
+	names    []string // registration order
+	datasets map[string]Dataset
+}
+
+// NewDatasetRegistry returns an empty registry
+func NewDatasetRegistry() *DatasetRegistry {
+	return &DatasetRegistry{datasets: map[string]Dataset{}}
+}
+
+// DefaultDatasets returns a registry holding the LanguageCandidates test,
+// read from inputDir and written to outputDir under main.go's file name
+func DefaultDatasets(inputDir, outputDir string) *DatasetRegistry {
+	r := NewDatasetRegistry()
+	r.Register("LanguageCandidates", TableDataset[LanguageCandidate](
+		filepath.Join(inputDir, "language_candidates.json"),
+		filepath.Join(outputDir, "language_candidates.json")))
+	return r
+}
+
+// Register adds a dataset; names must be unique and Load and Save set
+func (r *DatasetRegistry) Register(name string, d Dataset) error {
+	if _, dup := r.datasets[name]; dup {
+		return fmt.Errorf("dataset %s is already registered", name)
+	}
+	if d.Load == nil || d.Save == nil {
+		return fmt.Errorf("dataset %s needs a loader and a saver", name)
+	}
+	r.names = append(r.names, name)
+	r.datasets[name] = d
+	return nil
+}
+
+// Names returns the registered dataset names in registration order
+func (r *DatasetRegistry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Run loads, computes and saves one dataset, returning how many records it saved
+func (r *DatasetRegistry) Run(name string) (int, error) {
+	d, ok := r.datasets[name]
+	if !ok {
+		return 0, fmt.Errorf("no dataset %q (registered: %v)", name, r.names)
+	}
+
+	records, err := d.Load()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to load - %w", name, err)
+	}
+	if d.Compute == nil {
+		records = ComputeBatch(records)
+	} else if records, err = d.Compute(records); err != nil {
+		return 0, fmt.Errorf("%s: failed to compute - %w", name, err)
+	}
+	if err := d.Save(records); err != nil {
+		return 0, fmt.Errorf("%s: failed to save - %w", name, err)
+	}
+	return len(records), nil
+}
+
+// RunAll runs every dataset in registration order, carrying on past
+// failures. The results are in the same order; the error joins every
+// dataset's failure.
+func (r *DatasetRegistry) RunAll() ([]TableResult, error) {
+	results := make([]TableResult, len(r.names))
+	var errs []error
+	for i, name := range r.names {
+		n, err := r.Run(name)
+		results[i] = TableResult{Table: name, Records: n, Err: err}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDatasetRegistryRunAndRunAll(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "language_candidates.json")
+	saved := 0
+	r := NewDatasetRegistry()
+	if err := r.Register("candidates", TableDataset[LanguageCandidate]("testdata/flexbool/all-bool.json", out)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("broken", Dataset{
+		Load: func() ([]Record, error) { return nil, errors.New("source offline") },
+		Save: func([]Record) error { saved++; return nil },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("candidates", Dataset{}); err == nil {
+		t.Error("registering a duplicate name = nil, want an error")
+	}
+	if got := r.Names(); !slices.Equal(got, []string{"candidates", "broken"}) {
+		t.Errorf("Names = %v, want registration order", got)
+	}
+
+	n, err := r.Run("candidates")
+	if err != nil || n != 2 {
+		t.Fatalf("Run(candidates) = %d, %v, want 2 records", n, err)
+	}
+	computed, err := LoadLanguageCandidateRecords(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if computed[0].PredictedAnswer == nil {
+		t.Error("Run saved uncomputed records")
+	}
+	if _, err := r.Run("missing"); err == nil {
+		t.Error("Run of an unregistered dataset = nil, want an error")
+	}
+
+	results, err := r.RunAll()
+	if err == nil || !errors.Is(err, results[1].Err) {
+		t.Errorf("RunAll error = %v, want the broken dataset's failure", err)
+	}
+	if len(results) != 2 || results[0].Err != nil || results[0].Records != 2 || results[1].Err == nil {
+		t.Errorf("RunAll = %+v, want candidates to succeed despite broken failing", results)
+	}
+	if saved != 0 {
+		t.Error("a dataset that failed to load was saved")
+	}
+}
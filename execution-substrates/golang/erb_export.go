@@ -0,0 +1,298 @@
+// ERB SDK - Export Helpers (hand-written)
+// =======================================
+// Alternative output shapes for computed records.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlattenJSON flattens the JSON encoding of v into dot-delimited keys, e.g.
+// {"_meta": {"engine": "go"}} becomes {"_meta.engine": "go"}. Array
+// elements are keyed by index.
+func FlattenJSON(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode value: %w", err)
+	}
+
+	flat := map[string]any{}
+	flattenInto(flat, "", decoded)
+	return flat, nil
+}
+
+func flattenInto(flat map[string]any, prefix string, v any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) == 0 && prefix != "" {
+			flat[prefix] = t
+		}
+		for key, child := range t {
+			flattenInto(flat, join(key), child)
+		}
+	case []any:
+		if len(t) == 0 && prefix != "" {
+			flat[prefix] = t
+		}
+		for i, child := range t {
+			flattenInto(flat, join(strconv.Itoa(i)), child)
+		}
+	default:
+		flat[prefix] = t
+	}
+}
+
+// NamingConvention selects how MarshalView spells json keys
+type NamingConvention int
+
+const (
+	SnakeCase  NamingConvention = iota // language_candidate_id (the canonical names)
+	CamelCase                          // languageCandidateId
+	PascalCase                         // LanguageCandidateId
+)
+
+// convertName converts a canonical snake_case name to the convention
+func (c NamingConvention) convertName(snake string) string {
+	if c == SnakeCase {
+		return snake
+	}
+	words := strings.Split(snake, "_")
+	for i, w := range words {
+		if w == "" || (i == 0 && c == CamelCase) {
+			continue
+		}
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, "")
+}
+
+// MarshalView marshals a computed candidate with keys in the given naming
+// convention, keeping the struct's field order
+func MarshalView(view LanguageCandidate, convention NamingConvention) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, nv := range recordValues(&view) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(convention.convertName(nv.Name))
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(nv.Value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", nv.Name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Project returns just the named fields (json names) of each record, for slim
+// lookup tables. Every name must be a LanguageCandidate field; nulls stay nil.
+func Project(records []LanguageCandidate, fields []string) ([]map[string]any, error) {
+	var zero LanguageCandidate
+	known := fieldsByJSONName(&zero)
+	for _, name := range fields {
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+	}
+
+	rows := make([]map[string]any, len(records))
+	for i := range records {
+		values := fieldsByJSONName(&records[i])
+		row := make(map[string]any, len(fields))
+		for _, name := range fields {
+			row[name] = derefValue(values[name])
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// ComputeViewPatch returns the fields (json names) whose values differ
+// between prev and curr, as a JSON Merge Patch (RFC 7396): a field that
+// became null maps to nil, and unchanged fields are omitted
+func ComputeViewPatch(prev, curr LanguageCandidate) map[string]any {
+	before := recordValues(&prev)
+	patch := map[string]any{}
+	for i, nv := range recordValues(&curr) {
+		if !reflect.DeepEqual(before[i].Value, nv.Value) {
+			patch[nv.Name] = nv.Value
+		}
+	}
+	return patch
+}
+
+// ComputeJQPatch returns a jq program that turns the JSON array of before
+// (as SaveLanguageCandidateRecords writes it) into after, by setting each
+// changed field on the record with that language_candidate_id. Only field
+// changes are expressible, so before and after must hold the same ids in
+// the same order. No changes yield the identity program ".".
+func ComputeJQPatch(before, after []LanguageCandidate) (string, error) {
+	if len(before) != len(after) {
+		return "", fmt.Errorf("jq patch: before has %d records, after has %d", len(before), len(after))
+	}
+
+	var branches []string
+	for i := range after {
+		id := after[i].LanguageCandidateId
+		if before[i].LanguageCandidateId != id {
+			return "", fmt.Errorf("jq patch: record %d is %s in before but %s in after", i, before[i].LanguageCandidateId, id)
+		}
+		patch := ComputeViewPatch(before[i], after[i])
+		if len(patch) == 0 {
+			continue
+		}
+
+		fields := make([]string, 0, len(patch))
+		for name := range patch {
+			fields = append(fields, name)
+		}
+		sort.Strings(fields)
+		sets := make([]string, len(fields))
+		for j, name := range fields {
+			value, err := json.Marshal(patch[name])
+			if err != nil {
+				return "", fmt.Errorf("jq patch: %s.%s: %w", id, name, err)
+			}
+			sets[j] = fmt.Sprintf(".%s = %s", strconv.Quote(name), value)
+		}
+		idLiteral, _ := json.Marshal(id)
+		branches = append(branches, fmt.Sprintf(".language_candidate_id == %s then %s", idLiteral, strings.Join(sets, " | ")))
+	}
+
+	if len(branches) == 0 {
+		return ".", nil
+	}
+	return "map(\n  if " + strings.Join(branches, "\n  elif ") + "\n  else . end\n)\n", nil
+}
+
+// ViewerSummary holds the headline counts shown by the web viewer
+type ViewerSummary struct {
+	Total              int `json:"total"`
+	MarkedLanguages    int `json:"marked_languages"`
+	PredictedLanguages int `json:"predicted_languages"`
+	Mismatches         int `json:"mismatches"`
+}
+
+// ViewerBundle is everything a static web viewer needs, precomputed
+type ViewerBundle struct {
+	Candidates        []LanguageCandidate `json:"candidates"`
+	Summary           ViewerSummary       `json:"summary"`
+	Mismatches        []string            `json:"mismatches"`
+	RelationshipTally map[string]int      `json:"relationship_tally"`
+}
+
+// NewViewerBundle aggregates computed candidates for the viewer
+func NewViewerBundle(views []LanguageCandidate) ViewerBundle {
+	bundle := ViewerBundle{
+		Candidates:        views,
+		Summary:           ViewerSummary{Total: len(views)},
+		Mismatches:        []string{},
+		RelationshipTally: map[string]int{},
+	}
+	for i := range views {
+		tc := &views[i]
+		if boolVal(tc.IsLanguage) {
+			bundle.Summary.MarkedLanguages++
+		}
+		if boolVal(tc.PredictedAnswer) {
+			bundle.Summary.PredictedLanguages++
+		}
+		if boolVal(tc.PredictedAnswer) != boolVal(tc.IsLanguage) {
+			bundle.Summary.Mismatches++
+			bundle.Mismatches = append(bundle.Mismatches, tc.LanguageCandidateId)
+		}
+		bundle.RelationshipTally[stringVal(tc.RelationshipToConcept)]++
+	}
+	return bundle
+}
+
+// ExportViewerBundle writes the candidates and their aggregates as one JSON file
+func ExportViewerBundle(path string, views []LanguageCandidate) error {
+	if err := checkOutputPath(path); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(NewViewerBundle(views), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	return nil
+}
+
+// DisagreementHeatmapCSV writes a matrix of candidates (rows, in after's
+// order) by calculated fields (columns), with 1 where the field's value
+// differs between before and after and 0 where it does not. Candidates are
+// matched by language_candidate_id; one missing from before is an error.
+func DisagreementHeatmapCSV(path string, before, after []LanguageCandidate) error {
+	if err := checkOutputPath(path); err != nil {
+		return err
+	}
+
+	byId := make(map[string]*LanguageCandidate, len(before))
+	for i := range before {
+		byId[before[i].LanguageCandidateId] = &before[i]
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(append([]string{"language_candidate_id"}, LanguageCandidateCalculatedFields...))
+	for i := range after {
+		prev, ok := byId[after[i].LanguageCandidateId]
+		if !ok {
+			return fmt.Errorf("candidate %s is missing from before", after[i].LanguageCandidateId)
+		}
+		prevFields, currFields := fieldsByJSONName(prev), fieldsByJSONName(&after[i])
+
+		row := []string{after[i].LanguageCandidateId}
+		for _, name := range LanguageCandidateCalculatedFields {
+			changed := "0"
+			if !reflect.DeepEqual(derefValue(prevFields[name]), derefValue(currFields[name])) {
+				changed = "1"
+			}
+			row = append(row, changed)
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write heatmap: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write heatmap: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFlattenJSON(t *testing.T) {
+	record := struct {
+		Name string   `json:"name"`
+		Meta Metadata `json:"_meta"`
+		Tags []string `json:"tags"`
+	}{"Python", NewMetadata(3), []string{"a", "b"}}
+
+	flat, err := FlattenJSON(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name":                 "Python",
+		"_meta.engine":         "go",
+		"_meta.engine_version": EngineVersion,
+		"_meta.field_count":    float64(3),
+		"tags.0":               "a",
+		"tags.1":               "b",
+	}
+	if !reflect.DeepEqual(flat, want) {
+		t.Errorf("FlattenJSON = %v, want %v", flat, want)
+	}
+}
+
+func TestMarshalViewConventions(t *testing.T) {
+	python := candidateByID(t, computedCandidates(t), "python")
+	tests := []struct {
+		convention               NamingConvention
+		id, hasSyntax, predicted string
+	}{
+		{SnakeCase, "language_candidate_id", "has_syntax", "predicted_answer"},
+		{CamelCase, "languageCandidateId", "hasSyntax", "predictedAnswer"},
+		{PascalCase, "LanguageCandidateId", "HasSyntax", "PredictedAnswer"},
+	}
+	for _, tt := range tests {
+		data, err := MarshalView(python, tt.convention)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("MarshalView(%d) is not JSON: %v", tt.convention, err)
+		}
+		if fields[tt.id] != "python" || fields[tt.hasSyntax] != true || fields[tt.predicted] != true {
+			t.Errorf("MarshalView(%d) keys %s/%s/%s = %v, %v, %v", tt.convention, tt.id, tt.hasSyntax, tt.predicted, fields[tt.id], fields[tt.hasSyntax], fields[tt.predicted])
+		}
+		if len(fields) != len(LanguageCandidateRawFields)+len(LanguageCandidateCalculatedFields) {
+			t.Errorf("MarshalView(%d) has %d keys", tt.convention, len(fields))
+		}
+	}
+}
+
+func TestExportViewerBundle(t *testing.T) {
+	views := computedCandidates(t)
+	path := filepath.Join(t.TempDir(), "viewer.json")
+	if err := ExportViewerBundle(path, views); err != nil {
+		t.Fatal(err)
+	}
+
+	var bundle ViewerBundle
+	if err := json.Unmarshal(readFile(t, path), &bundle); err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffTestCandidates(views, bundle.Candidates); len(diffs) > 0 {
+		t.Errorf("bundle candidates differ: %+v", diffs)
+	}
+	if bundle.Summary.Total != len(views) {
+		t.Errorf("summary total = %d, want %d", bundle.Summary.Total, len(views))
+	}
+	if bundle.Summary.Mismatches != len(bundle.Mismatches) || !slices.Contains(bundle.Mismatches, "falsifier-a") {
+		t.Errorf("summary mismatches = %d, mismatch list = %v, want falsifier-a among them", bundle.Summary.Mismatches, bundle.Mismatches)
+	}
+	tally := 0
+	for _, n := range bundle.RelationshipTally {
+		tally += n
+	}
+	if tally != len(views) {
+		t.Errorf("relationship tally covers %d candidates, want %d", tally, len(views))
+	}
+}
+
+func TestComputeViewPatchOnlyChangedFields(t *testing.T) {
+	rb := loadTestRulebook(t)
+	python := candidateByID(t, rb.LanguageCandidates, "python")
+	prev := *python.ComputeAll()
+	python.CanBeHeld = ptr(true)
+	python.Category = nil
+	curr := *python.ComputeAll()
+
+	patch := ComputeViewPatch(prev, curr)
+	if v, ok := patch["can_be_held"]; !ok || v != true {
+		t.Errorf("patch can_be_held = %v, %t, want true", v, ok)
+	}
+	if v, ok := patch["category"]; !ok || v != nil {
+		t.Errorf("patch category = %v, %t, want null", v, ok)
+	}
+	if v, ok := patch["predicted_answer"]; !ok || v != false {
+		t.Errorf("patch predicted_answer = %v, %t, want false", v, ok)
+	}
+	for _, unchanged := range []string{"language_candidate_id", "name", "has_syntax", "question"} {
+		if _, ok := patch[unchanged]; ok {
+			t.Errorf("patch has unchanged field %s", unchanged)
+		}
+	}
+	if patch := ComputeViewPatch(curr, curr); len(patch) != 0 {
+		t.Errorf("patch of a view with itself = %v, want empty", patch)
+	}
+}
+
+func TestDisagreementHeatmapCSVMarksChangedCells(t *testing.T) {
+	rb := loadTestRulebook(t)
+	python := candidateByID(t, rb.LanguageCandidates, "python")
+	mug := candidateByID(t, rb.LanguageCandidates, "a-coffee-mug")
+	before := []LanguageCandidate{*python.ComputeAll(), *mug.ComputeAll()}
+
+	python.CanBeHeld = ptr(true)
+	mug.Name = ptr("A Travel Mug")
+	after := []LanguageCandidate{*mug.ComputeAll(), *python.ComputeAll()}
+
+	path := filepath.Join(t.TempDir(), "heatmap.csv")
+	if err := DisagreementHeatmapCSV(path, before, after); err != nil {
+		t.Fatal(err)
+	}
+	want := "language_candidate_id," + strings.Join(LanguageCandidateCalculatedFields, ",") + "\n" +
+		"a-coffee-mug,0,1,0,0,0,0,0,0,0,0,0\n" +
+		"python,0,0,1,0,0,0,1,1,0,0,0\n"
+	if got := string(readFile(t, path)); got != want {
+		t.Errorf("heatmap =\n%s\nwant\n%s", got, want)
+	}
+
+	if err := DisagreementHeatmapCSV(path, before[:1], after); err == nil {
+		t.Error("DisagreementHeatmapCSV with a candidate missing from before = nil, want an error")
+	}
+}
+
+func TestComputeJQPatch(t *testing.T) {
+	computed := computedCandidates(t)
+	before := []LanguageCandidate{candidateByID(t, computed, "python"), candidateByID(t, computed, "english")}
+	after := slices.Clone(before)
+	after[0].Category = ptr("Programming Language")
+	after[0].SortOrder = nil
+
+	program, err := ComputeJQPatch(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "map(\n  if .language_candidate_id == \"python\" then .\"category\" = \"Programming Language\" | .\"sort_order\" = null\n  else . end\n)\n"
+	if program != want {
+		t.Errorf("ComputeJQPatch =\n%s\nwant\n%s", program, want)
+	}
+	if program, _ := ComputeJQPatch(before, before); program != "." {
+		t.Errorf("ComputeJQPatch without changes = %q, want .", program)
+	}
+	if _, err := ComputeJQPatch(before, []LanguageCandidate{after[1], after[0]}); err == nil {
+		t.Error("ComputeJQPatch with reordered records = nil, want an error")
+	}
+
+	jq, err := exec.LookPath("jq")
+	if err != nil {
+		t.Skip("jq not on PATH; not applying the program")
+	}
+	dir := t.TempDir()
+	beforePath, afterPath := filepath.Join(dir, "before.json"), filepath.Join(dir, "after.json")
+	if err := SaveLanguageCandidateRecords(beforePath, before); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveLanguageCandidateRecords(afterPath, after); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(jq, program, beforePath).Output()
+	if err != nil {
+		t.Fatalf("jq: %v", err)
+	}
+	var patched, expected any
+	if err := json.Unmarshal(out, &patched); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(readFile(t, afterPath), &expected); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(patched, expected) {
+		t.Errorf("jq output differs from after:\n%s", out)
+	}
+}
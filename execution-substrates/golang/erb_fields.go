@@ -0,0 +1,230 @@
+// ERB SDK - Field Access Helpers (hand-written)
+// =============================================
+// Reflection helpers for addressing generated struct fields by their json
+// (snake_case) names, as listed in the generated <Struct>RawFields and
+// <Struct>CalculatedFields slices.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// fieldsByJSONName maps json tag names to the fields of a pointer-to-struct record
+func fieldsByJSONName(record any) map[string]reflect.Value {
+	v := reflect.ValueOf(record).Elem()
+	t := v.Type()
+	fields := make(map[string]reflect.Value, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = v.Field(i)
+	}
+	return fields
+}
+
+// goFieldName returns the Go name of the struct field with the given json
+// name (LanguageCandidate, "resolves_to_an_ast" -> "ResolvesToAnAST"),
+// or the json name itself when there is no such field
+func goFieldName(record any, jsonName string) string {
+	t := reflect.TypeOf(record)
+	for i := 0; i < t.NumField(); i++ {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name == jsonName {
+			return t.Field(i).Name
+		}
+	}
+	return jsonName
+}
+
+// isNullField reports whether a field holds no value (nil pointer or empty string)
+func isNullField(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	case reflect.String:
+		return v.String() == ""
+	}
+	return false
+}
+
+// fieldCoverage returns the fraction of the named fields that are non-null on record
+func fieldCoverage(record any, names []string) float64 {
+	if len(names) == 0 {
+		return 1
+	}
+	fields := fieldsByJSONName(record)
+	present := 0
+	for _, name := range names {
+		if f, ok := fields[name]; ok && !isNullField(f) {
+			present++
+		}
+	}
+	return float64(present) / float64(len(names))
+}
+
+// clearFields resets the named fields of a pointer-to-struct record to their zero value
+func clearFields(record any, names []string) {
+	fields := fieldsByJSONName(record)
+	for _, name := range names {
+		if f, ok := fields[name]; ok {
+			f.Set(reflect.Zero(f.Type()))
+		}
+	}
+}
+
+// namedValue is one field of a record: its json name and dereferenced value (nil when null)
+type namedValue struct {
+	Name  string
+	Value any
+}
+
+// recordValues returns the fields of a pointer-to-struct record in struct order
+func recordValues(record any) []namedValue {
+	v := reflect.ValueOf(record).Elem()
+	t := v.Type()
+	values := make([]namedValue, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		values = append(values, namedValue{Name: name, Value: derefValue(v.Field(i))})
+	}
+	return values
+}
+
+// derefValue returns the value a field holds, dereferencing pointers (nil for a nil pointer)
+func derefValue(v reflect.Value) any {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+// NullDisplay is how human-readable renderers (command output, tables,
+// styled views) show a nil value. Data formats keep their own null: an
+// empty CSV cell, JSON and YAML null.
+var NullDisplay = "null"
+
+// displayValue renders a field value for text output, nil as NullDisplay
+func displayValue(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return NullDisplay
+	case *string:
+		if t == nil {
+			return NullDisplay
+		}
+		return *t
+	}
+	return fmt.Sprint(v)
+}
+
+// setCalculated stores a Calc* result in the named pointer field, following
+// ComputeAll's convention that an empty string result is stored as nil
+func setCalculated(record any, name string, value any) {
+	f, ok := fieldsByJSONName(record)[name]
+	if !ok {
+		return
+	}
+	if s, isString := value.(string); isString && s == "" {
+		f.Set(reflect.Zero(f.Type()))
+		return
+	}
+	p := reflect.New(f.Type().Elem())
+	p.Elem().Set(reflect.ValueOf(value))
+	f.Set(p)
+}
+
+// booleanPrefixes are the words a boolean field name should start with
+var booleanPrefixes = []string{"Is", "Has", "Can"}
+
+// knownMisspellings maps misspelled words seen in field names to their fix
+var knownMisspellings = map[string]string{
+	"Ongology":    "Ontology",
+	"Ontolgy":     "Ontology",
+	"Fued":        "Feud",
+	"Langauge":    "Language",
+	"Lanugage":    "Language",
+	"Grammer":     "Grammar",
+	"Neede":       "Needed",
+	"Seperate":    "Separate",
+	"Occurence":   "Occurrence",
+	"Refrence":    "Reference",
+	"Decsription": "Description",
+	"Identiy":     "Identity",
+}
+
+// nameWords splits a Go field name into words: on underscores and at case
+// changes, keeping acronyms together (ResolvesToAnAST -> Resolves To An AST)
+func nameWords(name string) []string {
+	var words []string
+	for _, part := range strings.Split(name, "_") {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			lowerToUpper := unicode.IsLower(runes[i-1]) && unicode.IsUpper(runes[i])
+			acronymEnd := unicode.IsUpper(runes[i-1]) && unicode.IsUpper(runes[i]) &&
+				i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if lowerToUpper || acronymEnd {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		if start < len(runes) {
+			words = append(words, string(runes[start:]))
+		}
+	}
+	return words
+}
+
+// CheckFieldNamingStyle reports fields of the generated structs that break
+// the naming style guide: boolean fields should start with Is, Has or Can
+// (after a one-word namespace such as Bio_), and no word may be a known
+// misspelling. Entries read "<Struct>.<Field>: <problem>".
+func CheckFieldNamingStyle() []string {
+	return checkFieldNamingStyle(LanguageCandidate{}, IsEverythingALanguage{}, ERBCustomization{})
+}
+
+// checkFieldNamingStyle is CheckFieldNamingStyle over the given structs
+func checkFieldNamingStyle(records ...any) []string {
+	var problems []string
+	for _, record := range records {
+		t := reflect.TypeOf(record)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Bool {
+				name := f.Name
+				if ns, rest, ok := strings.Cut(name, "_"); ok && len(nameWords(ns)) == 1 {
+					name = rest
+				}
+				words := nameWords(name)
+				if len(words) == 0 || !slices.Contains(booleanPrefixes, words[0]) {
+					problems = append(problems, fmt.Sprintf("%s.%s: boolean should start with %s",
+						t.Name(), f.Name, strings.Join(booleanPrefixes, "/")))
+				}
+			}
+
+			for _, w := range nameWords(f.Name) {
+				if fix, ok := knownMisspellings[w]; ok {
+					problems = append(problems, fmt.Sprintf("%s.%s: %q looks like a typo for %q", t.Name(), f.Name, w, fix))
+				}
+			}
+		}
+	}
+	return problems
+}
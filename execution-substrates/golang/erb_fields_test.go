@@ -0,0 +1,32 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckFieldNamingStyle(t *testing.T) {
+	type legacyCandidate struct {
+		IsOngologyDescriptor *bool
+		Bio_HasFeedback      *bool
+		FamilyFuedQuestion   *string
+		Parsed               bool
+	}
+	got := checkFieldNamingStyle(legacyCandidate{})
+	want := []string{
+		`legacyCandidate.IsOngologyDescriptor: "Ongology" looks like a typo for "Ontology"`,
+		`legacyCandidate.FamilyFuedQuestion: "Fued" looks like a typo for "Feud"`,
+		`legacyCandidate.Parsed: boolean should start with Is/Has/Can`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("checkFieldNamingStyle =\n%q\nwant\n%q", got, want)
+	}
+
+	// The generated structs carry no known misspellings
+	for _, p := range CheckFieldNamingStyle() {
+		if strings.Contains(p, "looks like a typo") {
+			t.Errorf("generated struct field misspelled: %s", p)
+		}
+	}
+}
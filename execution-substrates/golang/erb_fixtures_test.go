@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadTestRulebook loads the rulebook at DefaultRulebookPath, failing the test on error
+func loadTestRulebook(t testing.TB) *Rulebook {
+	t.Helper()
+	rb, err := LoadFromRulebook(DefaultRulebookPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rb
+}
+
+// computedCandidates returns every rulebook LanguageCandidate after ComputeAll
+func computedCandidates(t testing.TB) []LanguageCandidate {
+	t.Helper()
+	rb := loadTestRulebook(t)
+	computed := make([]LanguageCandidate, len(rb.LanguageCandidates))
+	for i := range rb.LanguageCandidates {
+		computed[i] = *rb.LanguageCandidates[i].ComputeAll()
+	}
+	return computed
+}
+
+// candidateByID returns the candidate with language_candidate_id id
+func candidateByID(t testing.TB, candidates []LanguageCandidate, id string) LanguageCandidate {
+	t.Helper()
+	for _, lc := range candidates {
+		if lc.LanguageCandidateId == id {
+			return lc
+		}
+	}
+	t.Fatalf("no candidate %q", id)
+	return LanguageCandidate{}
+}
+
+// ptr returns a pointer to v, for the generated structs' nullable fields
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// readFile returns the contents of path, failing the test on error
+func readFile(t testing.TB, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// writeFile writes data to name in a temporary directory and returns its path
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,66 @@
+// ERB SDK - Conditional Formatting (hand-written)
+// ===============================================
+// Declarative styling rules for report renderers. A rule names a field, a
+// condition over the whole computed view and a style; renderers look the
+// styles up instead of hardcoding which values to highlight.
+
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// FormatRule styles Field when Condition holds for the view. Condition
+// sees the whole view, so a rule may style one field on another's value.
+type FormatRule struct {
+	Field     string // json field name
+	Condition func(view *LanguageCandidate) bool
+	Style     string // e.g. "red", "green", "bold"
+}
+
+// DefaultFormatRules highlights a mismatch in red, a top answer in green and
+// an open/closed world conflict in yellow
+var DefaultFormatRules = []FormatRule{
+	{"prediction_fail", func(v *LanguageCandidate) bool { return v.PredictionFail != nil }, "red"},
+	{"predicted_answer", func(v *LanguageCandidate) bool { return boolVal(v.PredictedAnswer) }, "green"},
+	{"name", func(v *LanguageCandidate) bool { return v.PredictionFail != nil }, "bold"},
+	{"is_open_closed_world_conflicted", func(v *LanguageCandidate) bool { return boolVal(v.IsOpenClosedWorldConflicted) }, "yellow"},
+}
+
+// ApplyFormatRules returns the style of each field some rule matches for
+// view, keyed by json field name. When several rules match one field the
+// later rule wins, as in CSS.
+func ApplyFormatRules(view LanguageCandidate, rules []FormatRule) map[string]string {
+	styles := map[string]string{}
+	for _, r := range rules {
+		if r.Condition(&view) {
+			styles[r.Field] = r.Style
+		}
+	}
+	return styles
+}
+
+// ansiStyles maps FormatRule styles to terminal escape codes
+var ansiStyles = map[string]string{
+	"bold":   "\033[1m",
+	"red":    "\033[31m",
+	"green":  "\033[32m",
+	"yellow": "\033[33m",
+}
+
+// RenderStyledView writes view's fields one per line, wrapping each styled
+// field in its ANSI escape code (unknown styles are written plain)
+func RenderStyledView(w io.Writer, view LanguageCandidate, rules []FormatRule) error {
+	styles := ApplyFormatRules(view, rules)
+	for _, f := range recordValues(&view) {
+		line := fmt.Sprintf("%-38s %s", f.Name, displayValue(f.Value))
+		if code, ok := ansiStyles[styles[f.Name]]; ok {
+			line = code + line + "\033[0m"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
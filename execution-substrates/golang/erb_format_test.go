@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"maps"
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestRenderStyledViewUsesNullDisplay(t *testing.T) {
+	saved := NullDisplay
+	t.Cleanup(func() { NullDisplay = saved })
+	NullDisplay = "N/A"
+
+	mug := candidateByID(t, computedCandidates(t), "a-coffee-mug")
+	var buf bytes.Buffer
+	if err := RenderStyledView(&buf, mug, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`(?m)^prediction_fail +N/A$`).Match(buf.Bytes()) {
+		t.Errorf("null prediction_fail not rendered as N/A:\n%s", buf.String())
+	}
+	if bytes.Contains(buf.Bytes(), []byte("null")) {
+		t.Errorf("output still contains null:\n%s", buf.String())
+	}
+}
+
+func TestApplyFormatRules(t *testing.T) {
+	computed := computedCandidates(t)
+	falsifier := candidateByID(t, computed, "falsifier-a")
+	want := map[string]string{"prediction_fail": "red", "name": "bold"}
+	if got := ApplyFormatRules(falsifier, DefaultFormatRules); !maps.Equal(got, want) {
+		t.Errorf("falsifier-a styles = %v, want %v", got, want)
+	}
+
+	python := candidateByID(t, computed, "python")
+	if got := ApplyFormatRules(python, DefaultFormatRules); !maps.Equal(got, map[string]string{"predicted_answer": "green"}) {
+		t.Errorf("python styles = %v, want predicted_answer green only", got)
+	}
+
+	// The later of two rules on one field wins
+	rules := append(slices.Clone(DefaultFormatRules), FormatRule{"predicted_answer", func(*LanguageCandidate) bool { return true }, "bold"})
+	if got := ApplyFormatRules(python, rules)["predicted_answer"]; got != "bold" {
+		t.Errorf("predicted_answer style = %q, want the later rule's bold", got)
+	}
+}
@@ -0,0 +1,925 @@
+// ERB SDK - Formula Engine (hand-written)
+// =======================================
+// A Go port of the lexer and parser in orchestration/formula_parser.py, with
+// an evaluator over records keyed by rulebook field names ({{Name}} refers to
+// record["Name"]). This is the data-driven compute mode: it evaluates the
+// rulebook's formulas directly instead of the generated Calc* methods.
+//
+// Evaluation follows the generated code's lenient null handling: a null
+// boolean is false, a null string is "", and comparing a null number is
+// false (true for <>). FIND returns a 1-based position (0 when absent), so
+// it works both compared with > 0 and bare as a condition.
+//
+// Formulas are compiled once into an AST and evaluated per record.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// =============================================================================
+// LEXER
+// =============================================================================
+
+type tokenType int
+
+const (
+	tokString tokenType = iota
+	tokNumber
+	tokFieldRef
+	tokFuncName
+	tokLParen
+	tokRParen
+	tokComma
+	tokAmpersand
+	tokCompare // = <> < <= > >=
+	tokEOF
+)
+
+type token struct {
+	typ  tokenType
+	text string // string contents, field or function name, or operator
+	num  int
+	pos  int
+}
+
+// tokenizeFormula splits an Excel-dialect formula into tokens
+// (positions are byte offsets into formula, including any leading =)
+func tokenizeFormula(formula string) ([]token, error) {
+	var tokens []token
+
+	start := 0
+	if strings.HasPrefix(formula, "=") {
+		start = 1
+	}
+	for i := start; i < len(formula); {
+		c := formula[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '"':
+			j := i + 1
+			for j < len(formula) && formula[j] != '"' {
+				if formula[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(formula) {
+				return nil, formulaErrorf(formula, i, "unterminated string")
+			}
+			tokens = append(tokens, token{typ: tokString, text: formula[i+1 : j], pos: i})
+			i = j + 1
+
+		case strings.HasPrefix(formula[i:], "{{"):
+			j := strings.Index(formula[i:], "}}")
+			if j == -1 {
+				return nil, formulaErrorf(formula, i, "unterminated field reference")
+			}
+			tokens = append(tokens, token{typ: tokFieldRef, text: formula[i+2 : i+j], pos: i})
+			i += j + 2
+
+		case isDigit(c) || (c == '-' && i+1 < len(formula) && isDigit(formula[i+1])):
+			j := i + 1
+			for j < len(formula) && isDigit(formula[j]) {
+				j++
+			}
+			n, err := strconv.Atoi(formula[i:j])
+			if err != nil {
+				return nil, formulaErrorf(formula, i, "bad number: %v", err)
+			}
+			tokens = append(tokens, token{typ: tokNumber, num: n, pos: i})
+			i = j
+
+		case strings.HasPrefix(formula[i:], "<>"), strings.HasPrefix(formula[i:], "<="), strings.HasPrefix(formula[i:], ">="):
+			tokens = append(tokens, token{typ: tokCompare, text: formula[i : i+2], pos: i})
+			i += 2
+
+		case c == '<' || c == '>' || c == '=':
+			tokens = append(tokens, token{typ: tokCompare, text: string(c), pos: i})
+			i++
+
+		case c == '&':
+			tokens = append(tokens, token{typ: tokAmpersand, text: "&", pos: i})
+			i++
+		case c == '(':
+			tokens = append(tokens, token{typ: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{typ: tokRParen, text: ")", pos: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{typ: tokComma, text: ",", pos: i})
+			i++
+
+		case isLetter(c) || c == '_':
+			j := i
+			for j < len(formula) && (isLetter(formula[j]) || isDigit(formula[j]) || formula[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{typ: tokFuncName, text: strings.ToUpper(formula[i:j]), pos: i})
+			i = j
+
+		default:
+			return nil, formulaErrorf(formula, i, "unexpected character %q", c)
+		}
+	}
+
+	return append(tokens, token{typ: tokEOF, pos: len(formula)}), nil
+}
+
+// FormulaError is a formula parse error at a byte offset of the formula
+type FormulaError struct {
+	Formula string
+	Offset  int
+	Msg     string
+}
+
+func formulaErrorf(formula string, offset int, format string, args ...any) *FormulaError {
+	return &FormulaError{Formula: formula, Offset: offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *FormulaError) Error() string {
+	return fmt.Sprintf("%s at offset %d\n%s", e.Msg, e.Offset, e.Snippet())
+}
+
+// Snippet returns the formula line holding the error with a caret under the
+// offending byte
+func (e *FormulaError) Snippet() string {
+	start := strings.LastIndexByte(e.Formula[:e.Offset], '\n') + 1
+	end := len(e.Formula)
+	if i := strings.IndexByte(e.Formula[e.Offset:], '\n'); i >= 0 {
+		end = e.Offset + i
+	}
+
+	// Keep tabs in the padding so the caret lines up
+	pad := []byte(e.Formula[start:e.Offset])
+	for i, c := range pad {
+		if c != '\t' {
+			pad[i] = ' '
+		}
+	}
+	return "  " + e.Formula[start:end] + "\n  " + string(pad) + "^"
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+// =============================================================================
+// PARSER
+// =============================================================================
+
+// formulaNode is a node of a parsed formula
+type formulaNode interface {
+	eval(record map[string]any) (any, error)
+}
+
+type literalNode struct{ value any }
+
+type fieldNode struct{ name string }
+
+type compareNode struct {
+	op          string
+	left, right formulaNode
+}
+
+type notNode struct{ operand formulaNode }
+
+type callNode struct {
+	name string
+	args []formulaNode
+}
+
+type concatNode struct{ parts []formulaNode }
+
+// formulaArity is the argument count each function accepts (-1: any)
+var formulaArity = map[string][2]int{
+	"AND":   {1, -1},
+	"OR":    {1, -1},
+	"IF":    {2, 3},
+	"NOT":   {1, 1},
+	"LOWER": {1, 1},
+	"FIND":  {2, 2},
+	"CAST":  {1, 2},
+	"SUM":   {0, -1},
+}
+
+// formulaParser is a recursive descent parser with the same precedence as
+// formula_parser.py: & binds loosest, then one comparison, then primaries
+type formulaParser struct {
+	source string
+	tokens []token
+	pos    int
+	fields []string // field references, in first-seen order
+	seen   map[string]bool
+}
+
+func (p *formulaParser) current() token { return p.tokens[p.pos] }
+
+func (p *formulaParser) consume(want tokenType) (token, error) {
+	tok := p.current()
+	if tok.typ != want {
+		return tok, formulaErrorf(p.source, tok.pos, "unexpected %s", describeToken(tok))
+	}
+	p.pos++
+	return tok, nil
+}
+
+func describeToken(tok token) string {
+	if tok.typ == tokEOF {
+		return "end of formula"
+	}
+	if tok.typ == tokNumber {
+		return strconv.Itoa(tok.num)
+	}
+	return strconv.Quote(tok.text)
+}
+
+func (p *formulaParser) parseConcat() (formulaNode, error) {
+	first, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	parts := []formulaNode{first}
+	for p.current().typ == tokAmpersand {
+		p.pos++
+		next, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, next)
+	}
+	if len(parts) == 1 {
+		return first, nil
+	}
+	return concatNode{parts}, nil
+}
+
+func (p *formulaParser) parseComparison() (formulaNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if p.current().typ != tokCompare {
+		return left, nil
+	}
+	op := p.current().text
+	p.pos++
+	right, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	return compareNode{op, left, right}, nil
+}
+
+func (p *formulaParser) parsePrimary() (formulaNode, error) {
+	tok := p.current()
+	switch tok.typ {
+	case tokString:
+		p.pos++
+		return literalNode{tok.text}, nil
+
+	case tokNumber:
+		p.pos++
+		return literalNode{tok.num}, nil
+
+	case tokFieldRef:
+		p.pos++
+		if !p.seen[tok.text] {
+			p.seen[tok.text] = true
+			p.fields = append(p.fields, tok.text)
+		}
+		return fieldNode{tok.text}, nil
+
+	case tokFuncName:
+		p.pos++
+		if tok.text == "TRUE" || tok.text == "FALSE" {
+			if p.current().typ == tokLParen {
+				p.pos++
+				if _, err := p.consume(tokRParen); err != nil {
+					return nil, err
+				}
+			}
+			return literalNode{tok.text == "TRUE"}, nil
+		}
+
+		arity, known := formulaArity[tok.text]
+		if _, ok := lookupBuiltin(tok.text); ok && !known {
+			arity, known = [2]int{0, -1}, true
+		}
+		if !known {
+			return nil, formulaErrorf(p.source, tok.pos, "unknown function %s", tok.text)
+		}
+		if _, err := p.consume(tokLParen); err != nil {
+			return nil, err
+		}
+		var args []formulaNode
+		if p.current().typ != tokRParen {
+			for {
+				arg, err := p.parseConcat()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if p.current().typ != tokComma {
+					break
+				}
+				p.pos++
+			}
+		}
+		if _, err := p.consume(tokRParen); err != nil {
+			return nil, err
+		}
+		if len(args) < arity[0] || (arity[1] >= 0 && len(args) > arity[1]) {
+			return nil, formulaErrorf(p.source, tok.pos, "%s: wrong number of arguments (%d)", tok.text, len(args))
+		}
+
+		if tok.text == "NOT" {
+			return notNode{args[0]}, nil
+		}
+		return callNode{tok.text, args}, nil
+
+	case tokLParen:
+		p.pos++
+		expr, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.consume(tokRParen); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+
+	return nil, formulaErrorf(p.source, tok.pos, "unexpected %s", describeToken(tok))
+}
+
+// =============================================================================
+// COMPILED FORMULAS
+// =============================================================================
+
+// CompiledFormula is a formula parsed once, for evaluation against many records
+type CompiledFormula struct {
+	Source string
+	Fields []string // referenced field names, in first-seen order
+	root   formulaNode
+}
+
+// CompileFormula parses a rulebook formula (with or without the leading =).
+// Parse errors wrap a *FormulaError giving the byte offset.
+func CompileFormula(formula string) (*CompiledFormula, error) {
+	tokens, err := tokenizeFormula(formula)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse formula: %w", err)
+	}
+
+	p := &formulaParser{source: formula, tokens: tokens, seen: map[string]bool{}}
+	root, err := p.parseConcat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse formula: %w", err)
+	}
+	if tok := p.current(); tok.typ != tokEOF {
+		return nil, fmt.Errorf("failed to parse formula: %w", formulaErrorf(formula, tok.pos, "unexpected %s", describeToken(tok)))
+	}
+
+	return &CompiledFormula{Source: formula, Fields: p.fields, root: root}, nil
+}
+
+// Eval evaluates the formula against a record keyed by field name. Missing
+// keys are null. The result is nil, bool, int, float64 or string.
+func (f *CompiledFormula) Eval(record map[string]any) (any, error) {
+	return f.root.eval(record)
+}
+
+// FormulaEngine evaluates formula text directly, compiling each distinct
+// formula once and caching it. Unlike CompiledFormula.Eval, a reference to a
+// field the record has no key for is an error rather than null, so a typo
+// in a new rulebook formula is caught instead of evaluating to "".
+type FormulaEngine struct {
+	mu       sync.Mutex
+	compiled map[string]*CompiledFormula
+}
+
+// NewFormulaEngine returns an engine with an empty formula cache
+func NewFormulaEngine() *FormulaEngine {
+	return &FormulaEngine{compiled: map[string]*CompiledFormula{}}
+}
+
+// Eval evaluates formula against a record keyed by rulebook field name
+func (e *FormulaEngine) Eval(formula string, record map[string]any) (any, error) {
+	e.mu.Lock()
+	f, ok := e.compiled[formula]
+	if !ok {
+		var err error
+		if f, err = CompileFormula(formula); err != nil {
+			e.mu.Unlock()
+			return nil, err
+		}
+		e.compiled[formula] = f
+	}
+	e.mu.Unlock()
+
+	for _, name := range f.Fields {
+		if _, ok := record[name]; !ok {
+			return nil, fmt.Errorf("formula %s: unknown field {{%s}}", formula, name)
+		}
+	}
+	return f.Eval(record)
+}
+
+// Run evaluates formula against a generated record such as
+// *LanguageCandidate. Generated field names are the rulebook field names,
+// so {{DistanceFromConcept}} reads lc.DistanceFromConcept; every field of
+// the struct is known, with nil pointers as null.
+func (e *FormulaEngine) Run(formula string, record any) (any, error) {
+	v := reflect.ValueOf(record)
+	if v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("formula %s: record is a %T, not a generated struct", formula, record)
+	}
+	fields := make(map[string]any, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		fields[v.Type().Field(i).Name] = formulaValue(v.Field(i).Interface())
+	}
+	return e.Eval(formula, fields)
+}
+
+// =============================================================================
+// EVALUATION
+// =============================================================================
+
+func (n literalNode) eval(map[string]any) (any, error) { return n.value, nil }
+
+func (n fieldNode) eval(record map[string]any) (any, error) {
+	return formulaValue(record[n.name]), nil
+}
+
+func (n notNode) eval(record map[string]any) (any, error) {
+	v, err := n.operand.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	return !truthy(v), nil
+}
+
+func (n concatNode) eval(record map[string]any) (any, error) {
+	var sb strings.Builder
+	for _, part := range n.parts {
+		v, err := part.eval(record)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(formulaText(v))
+	}
+	return sb.String(), nil
+}
+
+func (n compareNode) eval(record map[string]any) (any, error) {
+	left, err := n.left.eval(record)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var cmp int
+	_, leftString := left.(string)
+	_, rightString := right.(string)
+	_, leftBool := left.(bool)
+	_, rightBool := right.(bool)
+	switch {
+	case leftString || rightString:
+		cmp = strings.Compare(formulaText(left), formulaText(right))
+	case leftBool || rightBool || (left == nil && right == nil):
+		cmp = boolCompare(truthy(left), truthy(right))
+	default:
+		l, lok := formulaNumber(left)
+		r, rok := formulaNumber(right)
+		if !lok || !rok {
+			// Null numbers compare false, as in the generated nil checks
+			return n.op == "<>", nil
+		}
+		switch {
+		case l < r:
+			cmp = -1
+		case l > r:
+			cmp = 1
+		}
+	}
+
+	switch n.op {
+	case "=":
+		return cmp == 0, nil
+	case "<>":
+		return cmp != 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default: // ">="
+		return cmp >= 0, nil
+	}
+}
+
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
+
+func (n callNode) eval(record map[string]any) (any, error) {
+	arg := func(i int) (any, error) { return n.args[i].eval(record) }
+
+	switch n.name {
+	case "AND", "OR":
+		// Arguments after the deciding one are never evaluated, and null is
+		// false, as with boolVal in the generated Calc* methods
+		want := n.name == "OR" // the value that decides the result early
+		for i := range n.args {
+			v, err := arg(i)
+			if err != nil {
+				return nil, err
+			}
+			if truthy(v) == want {
+				return want, nil
+			}
+		}
+		return !want, nil
+
+	case "IF":
+		// Only the taken branch is evaluated, so an untaken one may error
+		cond, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+		if truthy(cond) {
+			return arg(1)
+		}
+		if len(n.args) > 2 {
+			return arg(2)
+		}
+		return nil, nil
+
+	case "LOWER":
+		v, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+		return strings.ToLower(formulaText(v)), nil
+
+	case "FIND":
+		needle, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+		haystack, err := arg(1)
+		if err != nil {
+			return nil, err
+		}
+		// 1-based character position as in Airtable and PostgreSQL strpos; 0 when absent
+		h := formulaText(haystack)
+		i := strings.Index(h, formulaText(needle))
+		if i < 0 {
+			return 0, nil
+		}
+		return utf8.RuneCountInString(h[:i]) + 1, nil
+
+	case "CAST":
+		// CAST(x) casts to TEXT; CAST(x, "INT") names the target type
+		v, err := arg(0)
+		if err != nil {
+			return nil, err
+		}
+		target := any("TEXT")
+		if len(n.args) > 1 {
+			if target, err = arg(1); err != nil {
+				return nil, err
+			}
+		}
+		return Cast(v, formulaText(target))
+
+	case "SUM":
+		sum := 0.0
+		for i := range n.args {
+			v, err := arg(i)
+			if err != nil {
+				return nil, err
+			}
+			if b, ok := v.(bool); ok {
+				if b {
+					sum++
+				}
+				continue
+			}
+			if x, ok := formulaNumber(v); ok {
+				sum += x
+			}
+		}
+		return numberResult(sum), nil
+	}
+
+	if fn, ok := lookupBuiltin(n.name); ok {
+		args := make([]any, len(n.args))
+		for i := range n.args {
+			v, err := arg(i)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return fn(args)
+	}
+	return nil, fmt.Errorf("unknown function %s", n.name)
+}
+
+// Cast converts a formula value to TEXT, BOOL or INT (case-insensitive;
+// BOOLEAN and INTEGER also work) with PostgreSQL's cast semantics: null
+// stays null, booleans cast to the text "true"/"false" and to 1/0, text
+// casts to a boolean only from t/true/y/yes/on/1 or f/false/n/no/off/0, and
+// text that is not an integer is an error.
+func Cast(value any, targetType string) (any, error) {
+	value = formulaValue(value)
+	if value == nil {
+		return nil, nil
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(targetType)) {
+	case "TEXT":
+		return formulaText(value), nil
+
+	case "BOOL", "BOOLEAN":
+		switch t := value.(type) {
+		case bool:
+			return t, nil
+		case int:
+			return t != 0, nil
+		case string:
+			switch strings.ToLower(strings.TrimSpace(t)) {
+			case "t", "true", "y", "yes", "on", "1":
+				return true, nil
+			case "f", "false", "n", "no", "off", "0":
+				return false, nil
+			}
+			return nil, fmt.Errorf("invalid input syntax for type boolean: %q", t)
+		}
+
+	case "INT", "INTEGER":
+		switch t := value.(type) {
+		case bool:
+			if t {
+				return 1, nil
+			}
+			return 0, nil
+		case int:
+			return t, nil
+		case float64:
+			return int(math.RoundToEven(t)), nil
+		case string:
+			n, err := strconv.Atoi(strings.TrimSpace(t))
+			if err != nil {
+				return nil, fmt.Errorf("invalid input syntax for type integer: %q", t)
+			}
+			return n, nil
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported cast target type %q (want TEXT, BOOL or INT)", targetType)
+	}
+	return nil, fmt.Errorf("cannot cast %v (%T) to %s", value, value, targetType)
+}
+
+// BuiltinFunc implements a formula function added with RegisterBuiltin. Its
+// arguments are already evaluated to nil, bool, int, float64 or string.
+type BuiltinFunc func(args []any) (any, error)
+
+// builtins holds the functions added with RegisterBuiltin, by upper-cased
+// name; builtinsMu guards it, since formulas may be compiled and evaluated
+// concurrently with a registration
+var (
+	builtinsMu sync.RWMutex
+	builtins   = map[string]BuiltinFunc{}
+)
+
+// RegisterBuiltin makes fn callable from formulas as name (case-insensitive),
+// with any number of arguments. Register before compiling formulas that use
+// it. The core functions (IF, AND, FIND, ...) cannot be replaced.
+func RegisterBuiltin(name string, fn BuiltinFunc) {
+	name = strings.ToUpper(name)
+	if _, core := formulaArity[name]; core || name == "TRUE" || name == "FALSE" {
+		panic("formula: cannot replace core function " + name)
+	}
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+	builtins[name] = fn
+}
+
+// lookupBuiltin returns the registered function called name (upper-cased)
+func lookupBuiltin(name string) (BuiltinFunc, bool) {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	fn, ok := builtins[name]
+	return fn, ok
+}
+
+// formulaValue normalizes a record value to nil, bool, int, float64 or string,
+// dereferencing the pointer fields used by the generated structs
+func formulaValue(v any) any {
+	switch t := v.(type) {
+	case *bool:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case *int:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case *string:
+		if t == nil {
+			return nil
+		}
+		return *t
+	case float64:
+		return numberResult(t)
+	case json.Number:
+		if n, err := t.Float64(); err == nil {
+			return numberResult(n)
+		}
+		return t.String()
+	}
+	return v
+}
+
+// numberResult returns integral numbers as int
+func numberResult(x float64) any {
+	if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+		return int(x)
+	}
+	return x
+}
+
+func formulaNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case int:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
+
+// truthy converts a value to a boolean: null, false, 0 and "" are false
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case string:
+		return t != ""
+	case nil:
+		return false
+	}
+	x, _ := formulaNumber(v)
+	return x != 0
+}
+
+// formulaText converts a value to text for & and string functions (null is "")
+func formulaText(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case bool:
+		return boolToString(t)
+	case int:
+		return strconv.Itoa(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// =============================================================================
+// BATCH COMPUTE
+// =============================================================================
+
+// CompiledTable is a rulebook table's calculated fields, each formula
+// compiled once, ready to evaluate over many records
+type CompiledTable struct {
+	Fields    []string // calculated field names, in evaluation (dependency) order
+	Formulas  map[string]*CompiledFormula
+	Datatypes map[string]string
+}
+
+// CompileTable compiles every calculated field of table
+func CompileTable(table *RulebookTable) (*CompiledTable, error) {
+	ct := &CompiledTable{Formulas: map[string]*CompiledFormula{}, Datatypes: map[string]string{}}
+	for _, f := range table.Schema {
+		if f.Type != "calculated" {
+			continue
+		}
+		compiled, err := CompileFormula(f.Formula)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		ct.Formulas[f.Name] = compiled
+		ct.Datatypes[f.Name] = f.Datatype
+	}
+
+	fields, err := ResolveDAG(table.Schema)
+	if err != nil {
+		return nil, err
+	}
+	ct.Fields = fields
+	return ct, nil
+}
+
+// Compute returns a copy of record with every calculated field evaluated,
+// coerced to the field's datatype (empty strings become nil, like ComputeAll)
+func (ct *CompiledTable) Compute(record map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(record)+len(ct.Fields))
+	for k, v := range record {
+		out[k] = formulaValue(v)
+	}
+
+	for _, name := range ct.Fields {
+		v, err := ct.Formulas[name].Eval(out)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		out[name] = coerceDatatype(v, ct.Datatypes[name])
+	}
+	return out, nil
+}
+
+// coerceDatatype converts a formula result to a rulebook datatype the way
+// ComputeAll stores it: empty text becomes nil
+func coerceDatatype(v any, datatype string) any {
+	switch datatype {
+	case "boolean":
+		return truthy(v)
+	case "integer":
+		x, _ := formulaNumber(v)
+		return int(x)
+	default:
+		if t := formulaText(v); t != "" {
+			return t
+		}
+		return nil
+	}
+}
+
+// ComputeRecords is the runtime-interpreted counterpart of the generated
+// ComputeAll: it evaluates table's formulas over records, compiling each
+// formula once (CompileTable) for the whole batch
+func ComputeRecords(table *RulebookTable, records []map[string]any) ([]map[string]any, error) {
+	ct, err := CompileTable(table)
+	if err != nil {
+		return nil, err
+	}
+
+	computed := make([]map[string]any, len(records))
+	for i, record := range records {
+		if computed[i], err = ct.Compute(record); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+	return computed, nil
+}
+
+// RecordMaps decodes a rulebook table's data rows into records for ComputeRecords
+func RecordMaps(table *RulebookTable) ([]map[string]any, error) {
+	records := make([]map[string]any, len(table.Data))
+	for i, row := range table.Data {
+		record := make(map[string]any, len(row))
+		for key, raw := range row {
+			var v any
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fmt.Errorf("row %d field %s: %w", i, key, err)
+			}
+			record[key] = formulaValue(v)
+		}
+		records[i] = record
+	}
+	return records, nil
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+var registerTestBuiltins = sync.OnceFunc(func() {
+	RegisterBuiltin("LEN", func(args []any) (any, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("LEN: wrong number of arguments (%d)", len(args))
+		}
+		return utf8.RuneCountInString(formulaText(args[0])), nil
+	})
+	RegisterBuiltin("FAIL", func([]any) (any, error) {
+		return nil, fmt.Errorf("FAIL evaluated")
+	})
+	RegisterBuiltin("PANIC", func([]any) (any, error) {
+		panic("PANIC evaluated after AND/OR was decided")
+	})
+})
+
+func TestFormulaEval(t *testing.T) {
+	registerTestBuiltins()
+
+	contains := `=FIND("language", LOWER({{Category}})) > 0`
+	relationship := `=IF({{DistanceFromConcept}} = 1, "IsMirrorOf", "IsDescriptionOf")`
+	// wantErr as want expects Eval to fail with an error containing it
+	type wantErr string
+	tests := []struct {
+		formula string
+		field   string
+		value   any
+		want    any
+	}{
+		{contains, "Category", "Formal language", true},
+		{contains, "Category", "Natural LANGUAGE", true}, // LOWER makes the match case-insensitive
+		{contains, "Category", "Music", false},
+		{contains, "Category", nil, false},
+		{`=FIND("language", LOWER({{Category}}))`, "Category", "Programming Language", 13},
+		{`=FIND("language", {{Category}})`, "Category", "Programming Language", 0}, // FIND itself is case-sensitive
+		{`=FIND("language", LOWER({{Category}}))`, "Category", "Music", 0},
+		{`=FIND("é", {{Category}})`, "Category", "café", 4}, // positions count characters, not bytes
+		{`=LEN({{Category}}) >= 5`, "Category", "Music", true},
+		{relationship, "DistanceFromConcept", 1, "IsMirrorOf"},
+		{relationship, "DistanceFromConcept", 2, "IsDescriptionOf"},
+		{relationship, "DistanceFromConcept", nil, "IsDescriptionOf"}, // null is never equal to 1
+		{`=IF({{DistanceFromConcept}}, "set", "unset")`, "DistanceFromConcept", nil, "unset"},
+		{`=IF({{Category}} = "Music", "yes", "no")`, "Category", "Music", "yes"},
+		{`=IF({{Category}} = "Music", "yes")`, "Category", "Art", nil},
+		{`=IF({{DistanceFromConcept}} = 1, "IsMirrorOf", FAIL())`, "DistanceFromConcept", 1, "IsMirrorOf"}, // the untaken branch is not evaluated
+		{`=IF({{DistanceFromConcept}} = 1, FAIL(), "IsDescriptionOf")`, "DistanceFromConcept", nil, "IsDescriptionOf"},
+		{`=AND(TRUE(), {{CanBeHeld}}, PANIC())`, "CanBeHeld", false, false}, // AND stops at the first false
+		{`=AND(TRUE(), {{CanBeHeld}}, PANIC())`, "CanBeHeld", nil, false},   // null counts as false
+		{`=AND({{CanBeHeld}}, TRUE(), TRUE())`, "CanBeHeld", true, true},
+		{`=OR({{CanBeHeld}}, PANIC())`, "CanBeHeld", true, true}, // OR stops at the first true
+		{`=OR({{CanBeHeld}}, FALSE())`, "CanBeHeld", nil, false},
+		{`=NOT({{CanBeHeld}})`, "CanBeHeld", nil, true},
+		{`=NOT({{CanBeHeld}})`, "CanBeHeld", true, false},
+		{`=CAST({{HasSyntax}})`, "HasSyntax", false, "false"}, // as PostgreSQL CAST(false AS TEXT), not ""
+		{`=CAST({{HasSyntax}})`, "HasSyntax", true, "true"},
+		{`=CAST({{HasSyntax}})`, "HasSyntax", nil, nil}, // null casts to null
+		{`=CAST({{HasSyntax}}, "INT")`, "HasSyntax", true, 1},
+		{`=CAST({{Category}}, "BOOL")`, "Category", "yes", true},
+		{`=CAST({{Category}}, "INT")`, "Category", " 42 ", 42},
+		{`=LOWER({{X}})`, "Category", "Music", wantErr("unknown field {{X}}")},
+	}
+
+	engine := NewFormulaEngine()
+	for _, tt := range tests {
+		got, err := engine.Eval(tt.formula, map[string]any{tt.field: tt.value})
+		if msg, ok := tt.want.(wantErr); ok {
+			if err == nil || !strings.Contains(err.Error(), string(msg)) {
+				t.Errorf("%s with %s=%s: got %v (err %v), want error %q", tt.formula, tt.field, displayValue(tt.value), got, err, msg)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s with %s=%s: got %v (err %v), want %v", tt.formula, tt.field, displayValue(tt.value), got, err, tt.want)
+		}
+	}
+}
+
+func TestRegisterBuiltinConcurrentWithEval(t *testing.T) {
+	engine := NewFormulaEngine()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterBuiltin(fmt.Sprintf("TESTCONST%d", i), func([]any) (any, error) { return i, nil })
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := engine.Eval(`=FIND("a", {{Category}})`, map[string]any{"Category": "Grammar"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, err := engine.Eval(`=TESTCONST3()`, nil); err != nil || got != 3 {
+		t.Errorf("TESTCONST3() = %v (err %v), want 3", got, err)
+	}
+}
+
+func TestComputeRecordsMatchesComputeAll(t *testing.T) {
+	rb := loadTestRulebook(t)
+	table := rb.Tables["LanguageCandidates"]
+	records, err := RecordMaps(table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates := slices.Clone(rb.LanguageCandidates)
+
+	// A mismatched candidate without a Name, so Question and PredictionFail
+	// render a null Name
+	j := slices.IndexFunc(candidates, func(lc LanguageCandidate) bool { return lc.ComputeAll().CalcPredictionFail() != "" })
+	if j < 0 {
+		t.Fatal("no candidate with a PredictionFail sentence in the rulebook")
+	}
+	nilName := maps.Clone(records[j])
+	nilName["Name"] = nil
+	records = append(records, nilName)
+	unnamed := candidates[j]
+	unnamed.Name = nil
+	candidates = append(candidates, unnamed)
+
+	computed, err := ComputeRecords(table, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PredictedAnswer (AND/OR/NOT included) and every other formula,
+	// evaluated directly, must agree with the generated Calc* methods
+	rt := reflect.TypeOf(LanguageCandidate{})
+	for j, record := range computed {
+		lc := candidates[j].ComputeAll()
+		for _, f := range table.Schema {
+			if f.Type != "calculated" {
+				continue
+			}
+			sf, _ := rt.FieldByName(f.Name)
+			jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			want := LanguageCandidateCalcFuncs[jsonName](lc)
+			if want == "" {
+				want = nil
+			}
+			if record[f.Name] != want {
+				t.Errorf("%s.%s: formula gives %#v, ComputeAll %#v", lc.LanguageCandidateId, f.Name, record[f.Name], want)
+			}
+		}
+	}
+}
+
+// has_grammar is a boolean everywhere in this substrate, and a nil
+// HasSyntax gives false: CalcHasGrammar, the stored ComputeAll field and the
+// rulebook formula must agree, and so must the CAST text form other
+// substrates write ("true" or "") after CoerceHasGrammar
+func TestHasGrammarAgreesForNilHasSyntax(t *testing.T) {
+	schema := loadTestRulebook(t).Tables["LanguageCandidates"].Schema
+	k := slices.IndexFunc(schema, func(f FieldDef) bool { return f.Name == "HasGrammar" })
+	if k < 0 {
+		t.Fatal("no HasGrammar formula in the rulebook")
+	}
+
+	engine := NewFormulaEngine()
+	for _, hasSyntax := range []*bool{nil, ptr(false), ptr(true)} {
+		want := boolVal(hasSyntax)
+		lc := LanguageCandidate{HasSyntax: hasSyntax}
+		name := displayValue(formulaValue(hasSyntax))
+
+		if got := lc.CalcHasGrammar(); got != want {
+			t.Errorf("has_syntax=%s: CalcHasGrammar() = %v, want %v", name, got, want)
+		}
+		if stored := lc.ComputeAll().HasGrammar; stored == nil || *stored != want {
+			t.Errorf("has_syntax=%s: ComputeAll HasGrammar = %s, want %v", name, displayValue(formulaValue(stored)), want)
+		}
+		formula, err := engine.Eval(schema[k].Formula, map[string]any{"HasSyntax": formulaValue(hasSyntax)})
+		if err != nil || coerceDatatype(formula, "boolean") != want {
+			t.Errorf("has_syntax=%s: formula = %v (err %v), want %v", name, formula, err, want)
+		}
+		text := ""
+		if want {
+			text = "true"
+		}
+		if coerced, _, _ := CoerceHasGrammar(text); coerced != want {
+			t.Errorf("has_syntax=%s: CoerceHasGrammar(%q) = %v, want %v", name, text, coerced, want)
+		}
+	}
+}
+
+func TestResolveDAGRejectsCycle(t *testing.T) {
+	_, err := ResolveDAG([]FieldDef{
+		{Name: "A", Type: "calculated", Formula: "={{B}} & {{Raw}}"},
+		{Name: "B", Type: "calculated", Formula: "={{A}}"},
+		{Name: "Raw", Type: "raw"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "A -> B -> A") {
+		t.Errorf("ResolveDAG on an A -> B -> A cycle: got error %v", err)
+	}
+}
+
+// rulebookFormulas returns every calculated field formula in the rulebook
+func rulebookFormulas(rb *Rulebook) []string {
+	var formulas []string
+	for _, name := range rb.TableNames {
+		for _, f := range rb.Tables[name].Schema {
+			if f.Type == "calculated" && f.Formula != "" {
+				formulas = append(formulas, f.Formula)
+			}
+		}
+	}
+	return formulas
+}
+
+// FuzzParseFormula checks that CompileFormula never panics, that every
+// parse error is a *FormulaError positioned inside the formula, and that
+// evaluating a compiled formula against an empty record never panics
+func FuzzParseFormula(f *testing.F) {
+	for _, formula := range rulebookFormulas(loadTestRulebook(f)) {
+		f.Add(formula)
+	}
+	f.Fuzz(func(t *testing.T, formula string) {
+		compiled, err := CompileFormula(formula)
+		if err != nil {
+			var fe *FormulaError
+			if !errors.As(err, &fe) {
+				t.Fatalf("%q: error without a position: %v", formula, err)
+			}
+			if fe.Offset < 0 || fe.Offset > len(formula) {
+				t.Fatalf("%q: offset %d outside formula of length %d", formula, fe.Offset, len(formula))
+			}
+			_ = fe.Error() // the snippet must render too
+			return
+		}
+		compiled.Eval(map[string]any{})
+	})
+}
@@ -0,0 +1,77 @@
+// ERB SDK - Name Index (hand-written)
+// ===================================
+// Token index over candidate names for interactive search.
+
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// nameTokens splits a name into lowercased alphanumeric tokens
+func nameTokens(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// BuildNameIndex maps lowercased name tokens to the ids of the candidates whose name contains them
+func BuildNameIndex(candidates []LanguageCandidate) map[string][]string {
+	index := map[string][]string{}
+	for i := range candidates {
+		id := candidates[i].LanguageCandidateId
+		seen := map[string]bool{}
+		for _, token := range nameTokens(stringVal(candidates[i].Name)) {
+			if !seen[token] {
+				seen[token] = true
+				index[token] = append(index[token], id)
+			}
+		}
+	}
+	return index
+}
+
+// SearchByName returns the ids matching any query token, best matches
+// first. Each query token scores 3 for an exact token match, 2 for a
+// prefix match and 1 for a substring match; ties are ordered by id.
+func SearchByName(index map[string][]string, query string) []string {
+	scores := map[string]int{}
+	for _, q := range nameTokens(query) {
+		best := map[string]int{}
+		for token, ids := range index {
+			score := 0
+			switch {
+			case token == q:
+				score = 3
+			case strings.HasPrefix(token, q):
+				score = 2
+			case strings.Contains(token, q):
+				score = 1
+			}
+			for _, id := range ids {
+				if score > best[id] {
+					best[id] = score
+				}
+			}
+		}
+		for id, score := range best {
+			if score > 0 {
+				scores[id] += score
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSearchByNamePartialToken(t *testing.T) {
+	candidates := []LanguageCandidate{
+		{LanguageCandidateId: "sign-language", Name: ptr("Sign Language")},
+		{LanguageCandidateId: "english", Name: ptr("English language")},
+		{LanguageCandidateId: "languages-of-math", Name: ptr("Lang of Math")},
+		{LanguageCandidateId: "a-coffee-mug", Name: ptr("A Coffee Mug")},
+	}
+	index := BuildNameIndex(candidates)
+	if ids := index["language"]; !slices.Equal(ids, []string{"sign-language", "english"}) {
+		t.Errorf(`index["language"] = %v, want [sign-language english]`, ids)
+	}
+
+	// "lang" is an exact token of one name and a prefix of "language"
+	if got, want := SearchByName(index, "Lang"), []string{"languages-of-math", "english", "sign-language"}; !slices.Equal(got, want) {
+		t.Errorf("SearchByName(Lang) = %v, want %v", got, want)
+	}
+	if got, want := SearchByName(index, "offe"), []string{"a-coffee-mug"}; !slices.Equal(got, want) {
+		t.Errorf("SearchByName(offe) = %v, want %v", got, want)
+	}
+	if got := SearchByName(index, "python"); len(got) != 0 {
+		t.Errorf("SearchByName(python) = %v, want none", got)
+	}
+}
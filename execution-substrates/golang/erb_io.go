@@ -0,0 +1,489 @@
+// ERB SDK - Additional Loaders and Savers (hand-written)
+// ======================================================
+// File I/O beyond the generated Load*Records / Save*Records pair.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// FieldMapping maps a source key to the canonical json field name it should load into
+type FieldMapping map[string]string
+
+// LoadCandidatesMapped loads LanguageCandidates from a JSON file whose keys
+// may use a different naming (e.g. "syntax" for "has_syntax"). Keys found in
+// mapping are renamed before decoding; all other keys are left as-is.
+func LoadCandidatesMapped(path string, mapping FieldMapping) ([]LanguageCandidate, error) {
+	rows, err := LoadRecords[map[string]json.RawMessage](path)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]LanguageCandidate, len(rows))
+	for i, row := range rows {
+		renamed := make(map[string]json.RawMessage, len(row))
+		for key, value := range row {
+			if canonical, ok := mapping[key]; ok {
+				key = canonical
+			}
+			if _, dup := renamed[key]; dup {
+				return nil, fmt.Errorf("record %d: field %q supplied more than once after mapping", i, key)
+			}
+			renamed[key] = value
+		}
+
+		remapped, err := json.Marshal(renamed)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: failed to remap: %w", i, err)
+		}
+		if err := json.Unmarshal(remapped, &records[i]); err != nil {
+			return nil, fmt.Errorf("record %d: failed to parse: %w", i, err)
+		}
+	}
+
+	return records, nil
+}
+
+// LoadCandidatesSubset streams a LanguageCandidates JSON array and returns,
+// in file order, only the records whose language_candidate_id is in ids
+// (e.g. a manifest of changed records). Other records are never fully
+// decoded. It is an error for a requested id to be absent from the file.
+func LoadCandidatesSubset(path string, ids []string) ([]LanguageCandidate, error) {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, fmt.Errorf("failed to parse JSON: %s is not a JSON array", path)
+	}
+	var records []LanguageCandidate
+	for i := 0; dec.More(); i++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("record %d: failed to parse: %w", i, err)
+		}
+		var key struct {
+			ID string `json:"language_candidate_id"`
+		}
+		if err := json.Unmarshal(raw, &key); err != nil {
+			return nil, fmt.Errorf("record %d: failed to parse: %w", i, err)
+		}
+		if found, ok := wanted[key.ID]; !ok || found {
+			continue
+		}
+		var record LanguageCandidate
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, fmt.Errorf("record %d: failed to parse: %w", i, err)
+		}
+		wanted[key.ID] = true
+		records = append(records, record)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !wanted[id] && !slices.Contains(missing, id) {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s has no record for language_candidate_id %s", path, strings.Join(missing, ", "))
+	}
+	return records, nil
+}
+
+// ParseFlexBool decodes a boolean written in any of the encodings legacy
+// exports use: JSON true/false, the integers 1/0, or the strings
+// "true"/"false", "t"/"f", "yes"/"no", "y"/"n" and "1"/"0" (case-insensitive).
+// null and "" decode as nil.
+func ParseFlexBool(raw json.RawMessage) (*bool, error) {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+
+	var b bool
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		b = t
+	case float64:
+		if t != 0 && t != 1 {
+			return nil, fmt.Errorf("number %v is not a boolean (want 0 or 1)", t)
+		}
+		b = t == 1
+	case string:
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "":
+			return nil, nil
+		case "true", "t", "yes", "y", "1":
+			b = true
+		case "false", "f", "no", "n", "0":
+			b = false
+		default:
+			return nil, fmt.Errorf("string %q is not a boolean", t)
+		}
+	default:
+		return nil, fmt.Errorf("%s is not a boolean", raw)
+	}
+	return &b, nil
+}
+
+// LoadCandidatesFlexible loads LanguageCandidates like LoadLanguageCandidateRecords,
+// but accepts any ParseFlexBool encoding in boolean fields, so a file mixing
+// true, 1 and "yes" loads the same as an all-boolean one
+func LoadCandidatesFlexible(path string) ([]LanguageCandidate, error) {
+	rows, err := LoadRecords[map[string]json.RawMessage](path)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]LanguageCandidate, len(rows))
+	for i, row := range rows {
+		fields := fieldsByJSONName(&records[i])
+		for key, value := range row {
+			f, ok := fields[key]
+			if !ok || f.Type() != reflect.TypeOf((*bool)(nil)) {
+				continue
+			}
+			b, err := ParseFlexBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: field %s: %w", i, key, err)
+			}
+			if row[key], err = json.Marshal(b); err != nil {
+				return nil, fmt.Errorf("record %d: field %s: %w", i, key, err)
+			}
+		}
+
+		normalized, err := json.Marshal(row)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: failed to normalize: %w", i, err)
+		}
+		if err := json.Unmarshal(normalized, &records[i]); err != nil {
+			return nil, fmt.Errorf("record %d: failed to parse: %w", i, err)
+		}
+	}
+
+	return records, nil
+}
+
+// EngineVersion identifies this Go SDK in saved metadata
+const EngineVersion = "1.0.0"
+
+// Metadata is the optional _meta object written with wrapped output
+type Metadata struct {
+	Engine        string     `json:"engine"`
+	EngineVersion string     `json:"engine_version"`
+	FieldCount    int        `json:"field_count"`
+	BoolFormat    BoolFormat `json:"bool_format,omitempty"` // how the records' booleans are written
+}
+
+// NewMetadata returns Metadata for this engine
+func NewMetadata(fieldCount int) Metadata {
+	return Metadata{Engine: "go", EngineVersion: EngineVersion, FieldCount: fieldCount}
+}
+
+// SaveWithMetadata saves records wrapped as {"table": ..., "_meta": ..., "records": [...]},
+// with the records' booleans written in meta.BoolFormat
+func SaveWithMetadata(path, tableName string, records any, meta Metadata) error {
+	if err := checkOutputPath(path); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("failed to marshal records: %w", err)
+	}
+	wrapped := struct {
+		Table   string          `json:"table"`
+		Meta    Metadata        `json:"_meta"`
+		Records json.RawMessage `json:"records"`
+	}{tableName, meta, meta.BoolFormat.applyJSON(data)}
+
+	data, err = json.MarshalIndent(wrapped, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal records: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+
+	return nil
+}
+
+// Fingerprint returns a SHA-256 of the records' JSON encoding. It covers the
+// records only, so wrapper metadata never changes the fingerprint.
+func Fingerprint(records any) (string, error) {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal records: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// SaveCandidatesStream writes the same indented JSON array as
+// SaveLanguageCandidateRecords, but encodes one record at a time instead of
+// building the whole document in memory. It writes to a temporary file in
+// the same directory and renames it into place, so a failed save never
+// leaves a partial file at path.
+func SaveCandidatesStream(path string, records []LanguageCandidate) (err error) {
+	if err := checkOutputPath(path); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tmp)
+	switch {
+	case records == nil:
+		w.WriteString("null") // as json.MarshalIndent encodes a nil slice
+	case len(records) == 0:
+		w.WriteString("[]")
+	default:
+		var element bytes.Buffer
+		enc := json.NewEncoder(&element)
+		enc.SetIndent("  ", "  ")
+
+		w.WriteString("[\n")
+		for i := range records {
+			element.Reset()
+			if err := enc.Encode(&records[i]); err != nil {
+				return fmt.Errorf("failed to marshal record %d: %w", i, err)
+			}
+			if i > 0 {
+				w.WriteString(",\n")
+			}
+			w.WriteString("  ")
+			w.Write(bytes.TrimSuffix(element.Bytes(), []byte("\n")))
+		}
+		w.WriteString("\n]")
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return nil
+}
+
+// AppendComputedJSONArray appends one record to the JSON array file at
+// path in place: it finds the closing bracket by reading back from the end
+// of the file and overwrites it with the new element, so the cost does not
+// grow with the file. The layout matches SaveLanguageCandidateRecords. A
+// missing or empty file, or one holding null, starts a new array.
+func AppendComputedJSONArray(path string, record LanguageCandidate) error {
+	if err := checkOutputPath(path); err != nil {
+		return err
+	}
+
+	var element bytes.Buffer
+	enc := json.NewEncoder(&element)
+	enc.SetIndent("  ", "  ")
+	if err := enc.Encode(&record); err != nil {
+		return fmt.Errorf("failed to marshal record: %w", err)
+	}
+	body := append([]byte("  "), bytes.TrimSuffix(element.Bytes(), []byte("\n"))...)
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open records: %w", err)
+	}
+	defer f.Close()
+
+	// Walk back over trailing whitespace to the closing bracket, then to the
+	// last byte before it: '[' for an empty array, the previous element's '}' otherwise
+	closing, err := lastNonSpace(f, -1)
+	if err != nil {
+		return err
+	}
+	var at int64
+	prefix := "[\n"
+	switch {
+	case closing < 0:
+		// missing or empty file
+	case closing == 3 && readAt(f, 0, 4) == "null":
+		// a saved nil slice
+	case readAt(f, closing, 1) != "]":
+		return fmt.Errorf("%s does not end with a JSON array", path)
+	default:
+		last, err := lastNonSpace(f, closing)
+		if err != nil {
+			return err
+		}
+		if last < 0 {
+			return fmt.Errorf("%s does not end with a JSON array", path)
+		}
+		at, prefix = last+1, ",\n"
+		if readAt(f, last, 1) == "[" {
+			prefix = "\n"
+		}
+	}
+
+	tail := append(append([]byte(prefix), body...), "\n]"...)
+	if _, err := f.WriteAt(tail, at); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	if err := f.Truncate(at + int64(len(tail))); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+	return f.Close()
+}
+
+// lastNonSpace returns the offset of the last non-whitespace byte of f
+// before offset end (-1 for the whole file), or -1 when there is none
+func lastNonSpace(f *os.File, end int64) (int64, error) {
+	if end < 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read records: %w", err)
+		}
+		end = info.Size()
+	}
+	buf := make([]byte, 64)
+	for end > 0 {
+		start := max(end-int64(len(buf)), 0)
+		chunk := buf[:end-start]
+		if _, err := f.ReadAt(chunk, start); err != nil {
+			return 0, fmt.Errorf("failed to read records: %w", err)
+		}
+		for i := len(chunk) - 1; i >= 0; i-- {
+			if c := chunk[i]; c != ' ' && c != '\n' && c != '\r' && c != '\t' {
+				return start + int64(i), nil
+			}
+		}
+		end = start
+	}
+	return -1, nil
+}
+
+// readAt returns n bytes of f at offset, or "" if they cannot be read
+func readAt(f *os.File, offset int64, n int) string {
+	b := make([]byte, n)
+	if _, err := f.ReadAt(b, offset); err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// MaxDecodeDepth is the deepest array/object nesting DecodeLimited accepts
+var MaxDecodeDepth = 32
+
+// LimitError reports untrusted input that exceeds a DecodeLimited limit
+type LimitError struct {
+	Limit string // "bytes" or "depth"
+	Max   int64
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("input exceeds the %s limit of %d", e.Limit, e.Max)
+}
+
+// readLimited reads all of r, returning a *LimitError as soon as it holds
+// more than maxBytes (which must not be negative)
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	if maxBytes < 0 {
+		return nil, fmt.Errorf("negative byte limit %d", maxBytes)
+	}
+	// Read one byte past the limit to tell "exactly maxBytes" from "more"
+	limit := maxBytes
+	if limit < math.MaxInt64 {
+		limit++
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, &LimitError{Limit: "bytes", Max: maxBytes}
+	}
+	return data, nil
+}
+
+// DecodeLimited decodes JSON from untrusted input into v, returning a
+// *LimitError when the input is longer than maxBytes or nests deeper than
+// MaxDecodeDepth. The input is checked before anything is decoded.
+func DecodeLimited(r io.Reader, v any, maxBytes int64) error {
+	data, err := readLimited(r, maxBytes)
+	if err != nil {
+		return err
+	}
+	if jsonDepth(data) > MaxDecodeDepth {
+		return &LimitError{Limit: "depth", Max: int64(MaxDecodeDepth)}
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse input: %w", err)
+	}
+	return nil
+}
+
+// jsonDepth returns the deepest array/object nesting in data, ignoring
+// brackets inside strings. Malformed input is left for json.Unmarshal.
+func jsonDepth(data []byte) int {
+	depth, deepest := 0, 0
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '[' || c == '{':
+			depth++
+			deepest = max(deepest, depth)
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return deepest
+}
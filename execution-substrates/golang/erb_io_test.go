@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLimited(t *testing.T) {
+	const input = `{"a": [1, 2, 3]}`
+	tests := []struct {
+		name     string
+		input    string
+		maxBytes int64
+		limit    string // LimitError.Limit, or "" for success
+	}{
+		{"within limit", input, int64(len(input)), ""},
+		{"one byte over", input, int64(len(input)) - 1, "bytes"},
+		{"no overflow at MaxInt64", input, math.MaxInt64, ""},
+		{"too deep", strings.Repeat("[", MaxDecodeDepth+1) + strings.Repeat("]", MaxDecodeDepth+1), 1 << 20, "depth"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			err := DecodeLimited(strings.NewReader(tt.input), &v, tt.maxBytes)
+			var limitErr *LimitError
+			switch {
+			case tt.limit == "" && err != nil:
+				t.Errorf("DecodeLimited = %v, want nil", err)
+			case tt.limit != "" && (!errors.As(err, &limitErr) || limitErr.Limit != tt.limit):
+				t.Errorf("DecodeLimited = %v, want a %s *LimitError", err, tt.limit)
+			}
+		})
+	}
+
+	var v any
+	if err := DecodeLimited(strings.NewReader(input), &v, -1); err == nil {
+		t.Error("DecodeLimited with a negative limit = nil, want an error")
+	}
+}
+
+func TestLoadCandidatesMapped(t *testing.T) {
+	path := writeFile(t, "mapped.json", []byte(`[{"language_candidate_id": "python", "syntax": true, "parsed": false, "name": "Python"}]`))
+
+	candidates, err := LoadCandidatesMapped(path, FieldMapping{"syntax": "has_syntax", "parsed": "is_parsed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("loaded %d candidates, want 1", len(candidates))
+	}
+	lc := candidates[0]
+	if lc.LanguageCandidateId != "python" || stringVal(lc.Name) != "Python" {
+		t.Errorf("unmapped fields = %q, %q, want python, Python", lc.LanguageCandidateId, stringVal(lc.Name))
+	}
+	if lc.HasSyntax == nil || !*lc.HasSyntax || lc.IsParsed == nil || *lc.IsParsed {
+		t.Errorf("mapped fields has_syntax=%s is_parsed=%s, want true, false", show3(lc.HasSyntax), show3(lc.IsParsed))
+	}
+
+	dup := writeFile(t, "dup.json", []byte(`[{"language_candidate_id": "python", "syntax": true, "has_syntax": false}]`))
+	if _, err := LoadCandidatesMapped(dup, FieldMapping{"syntax": "has_syntax"}); err == nil {
+		t.Error("LoadCandidatesMapped with a key mapped onto an existing one = nil, want an error")
+	}
+}
+
+func TestSaveWithMetadata(t *testing.T) {
+	records := computedCandidates(t)[:2]
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wrapped.json")
+
+	meta := NewMetadata(len(LanguageCandidateRawFields) + len(LanguageCandidateCalculatedFields))
+	if err := SaveWithMetadata(path, "language_candidates", records, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	var wrapped struct {
+		Table   string              `json:"table"`
+		Meta    Metadata            `json:"_meta"`
+		Records []LanguageCandidate `json:"records"`
+	}
+	if err := json.Unmarshal(readFile(t, path), &wrapped); err != nil {
+		t.Fatal(err)
+	}
+	if wrapped.Table != "language_candidates" {
+		t.Errorf("table = %q, want language_candidates", wrapped.Table)
+	}
+	if wrapped.Meta.Engine != "go" || wrapped.Meta.EngineVersion != EngineVersion || wrapped.Meta.FieldCount != meta.FieldCount {
+		t.Errorf("_meta = %+v, want %+v", wrapped.Meta, meta)
+	}
+
+	want, err := Fingerprint(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Fingerprint(wrapped.Records); err != nil || got != want {
+		t.Errorf("Fingerprint of the saved records = %s, %v, want %s", got, err, want)
+	}
+
+	// Metadata is outside the fingerprint
+	other := filepath.Join(dir, "other.json")
+	if err := SaveWithMetadata(other, "language_candidates", records, NewMetadata(1)); err != nil {
+		t.Fatal(err)
+	}
+	wrapped.Records = nil
+	if err := json.Unmarshal(readFile(t, other), &wrapped); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := Fingerprint(wrapped.Records); got != want {
+		t.Errorf("Fingerprint changed with the metadata: %s, want %s", got, want)
+	}
+}
+
+func TestLoadCandidatesFlexibleMixedEncodings(t *testing.T) {
+	mixed, err := LoadCandidatesFlexible("testdata/flexbool/mixed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	allBool, err := LoadLanguageCandidateRecords("testdata/flexbool/all-bool.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffTestCandidates(allBool, mixed); len(diffs) > 0 {
+		t.Errorf("mixed encodings load differently: %+v", diffs)
+	}
+
+	var computedMixed, computedAllBool []LanguageCandidate
+	for i := range mixed {
+		computedMixed = append(computedMixed, *mixed[i].ComputeAll())
+		computedAllBool = append(computedAllBool, *allBool[i].ComputeAll())
+	}
+	if diffs := DiffTestCandidates(computedAllBool, computedMixed); len(diffs) > 0 {
+		t.Errorf("mixed encodings compute differently: %+v", diffs)
+	}
+
+	bad := writeFile(t, "bad.json", []byte(`[{"language_candidate_id": "x", "has_syntax": 2}]`))
+	if _, err := LoadCandidatesFlexible(bad); err == nil {
+		t.Error(`LoadCandidatesFlexible with "has_syntax": 2 = nil, want an error`)
+	}
+}
+
+func TestSaveCandidatesStreamMatchesSaveLanguageCandidateRecords(t *testing.T) {
+	for name, records := range map[string][]LanguageCandidate{
+		"computed": computedCandidates(t),
+		"empty":    {},
+		"nil":      nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			want, got := filepath.Join(dir, "want.json"), filepath.Join(dir, "got.json")
+			if err := SaveLanguageCandidateRecords(want, records); err != nil {
+				t.Fatal(err)
+			}
+			if err := SaveCandidatesStream(got, records); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(readFile(t, got), readFile(t, want)) {
+				t.Errorf("SaveCandidatesStream wrote\n%s\nwant\n%s", readFile(t, got), readFile(t, want))
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+				t.Errorf("%d files in the output directory, want 2 (no temp file left)", len(entries))
+			}
+		})
+	}
+
+	if err := SaveCandidatesStream(filepath.Join(t.TempDir(), "erb_sdk.go"), nil); err == nil {
+		t.Error("SaveCandidatesStream to a .go file = nil, want an error")
+	}
+}
+
+func TestAppendComputedJSONArray(t *testing.T) {
+	records := computedCandidates(t)[:3]
+	dir := t.TempDir()
+	want := filepath.Join(dir, "want.json")
+	if err := SaveLanguageCandidateRecords(want, records); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		start []byte // file contents before appending; nil for no file
+		saved int    // records saved with SaveLanguageCandidateRecords instead
+	}{
+		{name: "missing"},
+		{name: "empty", start: []byte{}},
+		{name: "empty array", start: []byte("[]\n")},
+		{name: "null", start: []byte("null")},
+		{name: "after a saved record", saved: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "log.json")
+			switch {
+			case tt.saved > 0:
+				if err := SaveLanguageCandidateRecords(path, records[:tt.saved]); err != nil {
+					t.Fatal(err)
+				}
+			case tt.start != nil:
+				if err := os.WriteFile(path, tt.start, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, r := range records[tt.saved:] {
+				if err := AppendComputedJSONArray(path, r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := readFile(t, path); !bytes.Equal(got, readFile(t, want)) {
+				t.Errorf("appended file =\n%s\nwant\n%s", got, readFile(t, want))
+			}
+		})
+	}
+
+	notArray := writeFile(t, "object.json", []byte(`{"records": []}`))
+	if err := AppendComputedJSONArray(notArray, records[0]); err == nil {
+		t.Error("AppendComputedJSONArray to a JSON object = nil, want an error")
+	}
+}
+
+func TestLoadCandidatesSubset(t *testing.T) {
+	path := filepath.Join(defaultBlankTestsDir, "language_candidates.json")
+	all, err := LoadLanguageCandidateRecords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subset, err := LoadCandidatesSubset(path, []string{"python", "a-coffee-mug", "python"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []LanguageCandidate
+	for _, lc := range all {
+		if lc.LanguageCandidateId == "python" || lc.LanguageCandidateId == "a-coffee-mug" {
+			want = append(want, lc)
+		}
+	}
+	if len(subset) != 2 || subset[0].LanguageCandidateId != want[0].LanguageCandidateId {
+		t.Fatalf("LoadCandidatesSubset = %d records, want %d in file order", len(subset), len(want))
+	}
+	if diffs := DiffTestCandidates(want, subset); len(diffs) > 0 {
+		t.Errorf("subset differs from the full load: %+v", diffs)
+	}
+
+	_, err = LoadCandidatesSubset(path, []string{"python", "not-a-candidate"})
+	if err == nil || !strings.Contains(err.Error(), "not-a-candidate") {
+		t.Errorf("LoadCandidatesSubset with an absent id = %v, want an error naming it", err)
+	}
+}
+
+func TestSaveRefusesDenylistedPaths(t *testing.T) {
+	dir := t.TempDir()
+	records := computedCandidates(t)[:1]
+
+	goPath := filepath.Join(dir, "x.go")
+	err := SaveLanguageCandidateRecords(goPath, records)
+	if err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("saving to x.go: err = %v, want a refusal", err)
+	}
+	if _, err := os.Stat(goPath); !os.IsNotExist(err) {
+		t.Errorf("refused save still created %s", goPath)
+	}
+
+	ForceOverwrite = true
+	t.Cleanup(func() { ForceOverwrite = false })
+	if err := SaveLanguageCandidateRecords(goPath, records); err != nil {
+		t.Errorf("saving to x.go with ForceOverwrite: %v", err)
+	}
+	ForceOverwrite = false
+
+	if err := SaveLanguageCandidateRecords(filepath.Join(dir, "out.json"), records); err != nil {
+		t.Errorf("saving to out.json: %v", err)
+	}
+}
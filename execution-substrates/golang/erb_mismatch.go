@@ -0,0 +1,111 @@
+// ERB SDK - Mismatch Sentence Template (hand-written)
+// ===================================================
+// The generated CalcPredictionFail hardcodes its sentence. This file renders
+// the same sentence from a configurable text/template, so it can be reworded
+// or translated without touching the rulebook formula.
+//
+// Register ApplyMismatchTemplate as a PostProcessor to use it in ComputeView.
+// Unlike the formula, the template falls back to the candidate id when Name
+// is null, so the sentence never starts with a bare space.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// DefaultMismatchTemplate reproduces the PredictionFail formula's sentence
+const DefaultMismatchTemplate = "{{.Name}} {{.IsWord}} a Family Feud Language, but {{.MarkedWord}} marked as a 'Language Candidate.'"
+
+// openClosedConflictNote is appended to PredictionFail for conflicted candidates
+const openClosedConflictNote = " - Open World vs. Closed World Conflict."
+
+// mismatchFields are the placeholders a mismatch template may use
+type mismatchFields struct {
+	Name       string // the candidate's Name, or its id when Name is null
+	IsWord     string // "Is" / "Isn't", from PredictedAnswer
+	MarkedWord string // "Is" / "Is Not", from IsLanguage
+}
+
+// mismatchTemplate is the current template, set through SetMismatchTemplate
+var mismatchTemplate = template.Must(template.New("mismatch").Parse(DefaultMismatchTemplate))
+
+// SetMismatchTemplate replaces the mismatch sentence template. The template
+// must parse and may only use {{.Name}}, {{.IsWord}} and {{.MarkedWord}};
+// otherwise the current template is kept and an error is returned.
+func SetMismatchTemplate(text string) error {
+	tmpl, err := template.New("mismatch").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid mismatch template: %w", err)
+	}
+	// Unknown placeholders only fail at execution, so try it once
+	if err := tmpl.Execute(io.Discard, mismatchFields{}); err != nil {
+		return fmt.Errorf("invalid mismatch template: %w", err)
+	}
+	mismatchTemplate = tmpl
+	return nil
+}
+
+// MismatchSentence renders PredictionFail with the current template: the
+// mismatch sentence when PredictedAnswer disagrees with IsLanguage, followed
+// by the open/closed world conflict note. With DefaultMismatchTemplate it
+// equals CalcPredictionFail, except that a null Name renders as the
+// candidate id. Reads computed fields, so call it on a computed record.
+func (tc *LanguageCandidate) MismatchSentence() string {
+	var buf bytes.Buffer
+	if boolVal(tc.PredictedAnswer) != boolVal(tc.IsLanguage) {
+		fields := mismatchFields{Name: tc.displayName(), IsWord: "Isn't", MarkedWord: "Is Not"}
+		if boolVal(tc.PredictedAnswer) {
+			fields.IsWord = "Is"
+		}
+		if boolVal(tc.IsLanguage) {
+			fields.MarkedWord = "Is"
+		}
+		// Validated in SetMismatchTemplate, so execution cannot fail
+		mismatchTemplate.Execute(&buf, fields)
+	}
+	if boolVal(tc.IsOpenClosedWorldConflicted) {
+		buf.WriteString(openClosedConflictNote)
+	}
+	return buf.String()
+}
+
+// displayName is Name, or the candidate id when Name is null or empty
+func (tc *LanguageCandidate) displayName() string {
+	if name := stringVal(tc.Name); name != "" {
+		return name
+	}
+	return tc.LanguageCandidateId
+}
+
+// ApplyMismatchTemplate is a PostProcessor that rewrites PredictionFail
+// with the current mismatch template
+func ApplyMismatchTemplate(view *LanguageCandidate) {
+	view.PredictionFail = nilIfEmpty(view.MismatchSentence())
+}
+
+// ValidateMismatchMessages returns a problem line for each view whose
+// PredictionFail mismatch sentence starts with whitespace (a null Name in
+// the formula) or contains a doubled space. The conflict note, which
+// legitimately starts with a space, is not part of the sentence. Views
+// rendered through ApplyMismatchTemplate pass, since it falls back to the id.
+func ValidateMismatchMessages(views []LanguageCandidate) []string {
+	var problems []string
+	for _, view := range views {
+		sentence := strings.TrimSuffix(stringVal(view.PredictionFail), openClosedConflictNote)
+		if sentence == "" {
+			continue
+		}
+		if strings.TrimLeft(sentence, " \t\n") != sentence {
+			problems = append(problems, fmt.Sprintf("%s: mismatch sentence starts with whitespace: %q", view.LanguageCandidateId, sentence))
+		}
+		if strings.Contains(sentence, "  ") {
+			problems = append(problems, fmt.Sprintf("%s: mismatch sentence has a doubled space: %q", view.LanguageCandidateId, sentence))
+		}
+	}
+	return problems
+}
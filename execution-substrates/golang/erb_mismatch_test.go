@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// nilNameMismatch is predicted a language by its Hockett trait but not
+// marked as one, and has no Name
+func nilNameMismatch() LanguageCandidate {
+	return LanguageCandidate{LanguageCandidateId: "nil-name-probe", Bio_HasSemanticity: ptr(true)}
+}
+
+func TestMismatchSentenceNilNameFallsBackToID(t *testing.T) {
+	computed := ptr(nilNameMismatch()).ComputeAll()
+
+	// The formula itself renders a null Name as "", as every substrate does
+	sentence := " Is a Family Feud Language, but Is Not marked as a 'Language Candidate.'"
+	if got := stringVal(computed.PredictionFail); got != sentence {
+		t.Errorf("PredictionFail = %q, want %q", got, sentence)
+	}
+	if got, want := computed.MismatchSentence(), "nil-name-probe"+sentence; got != want {
+		t.Errorf("MismatchSentence() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateMismatchMessagesOnTemplatedPredictionFail(t *testing.T) {
+	views := append(computedCandidates(t), *ptr(nilNameMismatch()).ComputeAll())
+	if problems := ValidateMismatchMessages(views[len(views)-1:]); len(problems) != 1 {
+		t.Errorf("ValidateMismatchMessages(formula PredictionFail with a null Name) = %q, want a leading space", problems)
+	}
+	for i := range views {
+		ApplyMismatchTemplate(&views[i])
+	}
+	if problems := ValidateMismatchMessages(views); len(problems) > 0 {
+		t.Errorf("templated PredictionFail problems:\n%s", strings.Join(problems, "\n"))
+	}
+
+	bad := LanguageCandidate{LanguageCandidateId: "bad", PredictionFail: ptr(" Is a Family Feud Language,  but" + openClosedConflictNote)}
+	if problems := ValidateMismatchMessages([]LanguageCandidate{bad}); len(problems) != 2 {
+		t.Errorf("ValidateMismatchMessages(bad) = %q, want a leading space and a doubled space", problems)
+	}
+}
+
+func TestMismatchSentenceMatchesCalcPredictionFail(t *testing.T) {
+	views := computedCandidates(t)
+	for i := range views {
+		if got, want := views[i].MismatchSentence(), views[i].CalcPredictionFail(); got != want {
+			t.Errorf("%s: MismatchSentence() = %q, CalcPredictionFail() = %q", views[i].LanguageCandidateId, got, want)
+		}
+	}
+}
+
+func TestSetMismatchTemplate(t *testing.T) {
+	t.Cleanup(func() {
+		if err := SetMismatchTemplate(DefaultMismatchTemplate); err != nil {
+			t.Fatal(err)
+		}
+	})
+	computed := *ptr(nilNameMismatch()).ComputeAll()
+
+	if err := SetMismatchTemplate("{{.Name}}: predicted {{.IsWord}}, marked {{.MarkedWord}}"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := computed.MismatchSentence(), "nil-name-probe: predicted Is, marked Is Not"; got != want {
+		t.Errorf("MismatchSentence = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"{{.Nmae}} is wrong", "{{.Name"} {
+		if err := SetMismatchTemplate(bad); err == nil {
+			t.Errorf("SetMismatchTemplate(%q) = nil, want an error", bad)
+		}
+	}
+	if got, want := computed.MismatchSentence(), "nil-name-probe: predicted Is, marked Is Not"; got != want {
+		t.Errorf("MismatchSentence after rejected templates = %q, want the last valid one", got)
+	}
+}
@@ -0,0 +1,122 @@
+// ERB SDK - Parallel Runner (hand-written)
+// ========================================
+// The generated main.go processes RunnerTables one after another. This file
+// processes them concurrently: up to tableWorkers tables at once, each of
+// which may compute its records on goroutines drawn from a shared pool of
+// MaxRecordWorkers. Output is identical to the sequential runner, since
+// every record is written back to its input position.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// MaxRecordWorkers caps the record-computing goroutines shared by all
+// tables, so a run never has more than tableWorkers+MaxRecordWorkers
+// goroutines working
+var MaxRecordWorkers = runtime.GOMAXPROCS(0)
+
+// TableResult is the outcome of processing one table
+type TableResult struct {
+	Table   string
+	Records int
+	Err     error
+}
+
+// tableProcessor computes one table's blank test file into its answers file
+type tableProcessor struct {
+	file    string // file name in both the input and output directories
+	process func(inputPath, outputPath string, pool chan struct{}) (int, error)
+}
+
+// tableProcessors has an entry for each table with a generated struct and
+// calculated fields
+var tableProcessors = map[string]tableProcessor{
+	"LanguageCandidates": {"language_candidates.json", processLanguageCandidates},
+}
+
+// processLanguageCandidates is the LanguageCandidates step of main.go with
+// records computed on the pool
+func processLanguageCandidates(inputPath, outputPath string, pool chan struct{}) (int, error) {
+	records, err := LoadLanguageCandidateRecords(inputPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load - %w", err)
+	}
+	computed := computeRecordsParallel(records, func(r *LanguageCandidate) LanguageCandidate { return *r.ComputeAll() }, pool)
+	if err := SaveLanguageCandidateRecords(outputPath, computed); err != nil {
+		return 0, fmt.Errorf("failed to save - %w", err)
+	}
+	return len(computed), nil
+}
+
+// computeRecordsParallel computes records in order-preserving slots. A record
+// gets its own goroutine when the pool has a free slot and is computed on
+// the caller's goroutine otherwise, so a full pool never blocks.
+func computeRecordsParallel[T any](records []T, compute func(*T) T, pool chan struct{}) []T {
+	out := make([]T, len(records))
+	var wg sync.WaitGroup
+	for i := range records {
+		select {
+		case pool <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() { <-pool; wg.Done() }()
+				out[i] = compute(&records[i])
+			}()
+		default:
+			out[i] = compute(&records[i])
+		}
+	}
+	wg.Wait()
+	return out
+}
+
+// ProcessAllTablesParallel processes every RunnerTables table from inputDir
+// into outputDir, up to tableWorkers tables at a time (at least one). The
+// results are in RunnerTables order; the error joins every table's failure.
+func ProcessAllTablesParallel(inputDir, outputDir string, tableWorkers int) ([]TableResult, error) {
+	tableWorkers = max(tableWorkers, 1)
+	results := make([]TableResult, len(RunnerTables))
+	tables := make(chan int)
+	pool := make(chan struct{}, max(MaxRecordWorkers, 0))
+
+	var wg sync.WaitGroup
+	for range min(tableWorkers, len(RunnerTables)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tables {
+				name := RunnerTables[i]
+				results[i].Table = name
+				p, ok := tableProcessors[name]
+				if !ok {
+					results[i].Err = fmt.Errorf("%s: no processor for table", name)
+					continue
+				}
+				n, err := p.process(filepath.Join(inputDir, p.file), filepath.Join(outputDir, p.file), pool)
+				results[i].Records = n
+				if err != nil {
+					results[i].Err = fmt.Errorf("%s: %w", name, err)
+				}
+			}
+		}()
+	}
+	for i := range RunnerTables {
+		tables <- i
+	}
+	close(tables)
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestProcessAllTablesParallelMatchesSequential(t *testing.T) {
+	// The sequential runner in main.go: ComputeAll each record in order, then save
+	want := t.TempDir()
+	for _, name := range RunnerTables {
+		p, ok := tableProcessors[name]
+		if !ok {
+			t.Fatalf("%s: no processor for table", name)
+		}
+		records, err := LoadLanguageCandidateRecords(filepath.Join(defaultBlankTestsDir, p.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var computed []LanguageCandidate
+		for _, r := range records {
+			computed = append(computed, *r.ComputeAll())
+		}
+		if err := SaveLanguageCandidateRecords(filepath.Join(want, p.file), computed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	orig := MaxRecordWorkers
+	t.Cleanup(func() { MaxRecordWorkers = orig })
+	for _, recordWorkers := range []int{0, 1, 4, 64} {
+		for _, tableWorkers := range []int{0, 1, 4} {
+			t.Run(fmt.Sprintf("records=%d/tables=%d", recordWorkers, tableWorkers), func(t *testing.T) {
+				MaxRecordWorkers = recordWorkers
+				out := t.TempDir()
+				results, err := ProcessAllTablesParallel(defaultBlankTestsDir, out, tableWorkers)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i, name := range RunnerTables {
+					if results[i].Table != name {
+						t.Errorf("results[%d].Table = %q, want %q", i, results[i].Table, name)
+					}
+					file := tableProcessors[name].file
+					if got := readFile(t, filepath.Join(out, file)); !bytes.Equal(got, readFile(t, filepath.Join(want, file))) {
+						t.Errorf("%s: parallel output differs from the sequential runner", name)
+					}
+				}
+			})
+		}
+	}
+}
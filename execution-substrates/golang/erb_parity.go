@@ -0,0 +1,218 @@
+// ERB SDK - SQL Dump Parity (hand-written)
+// ========================================
+// Compares computed LanguageCandidates field-by-field with an export of the
+// postgres vw_language_candidates view, either
+//
+//   \copy (SELECT * FROM vw_language_candidates) TO 'lc.csv' WITH CSV HEADER
+//
+// or the COPY ... FROM stdin block of a pg_dump. Values are compared as SQL
+// text: booleans are t/f and NULL is NULL. Empty text also counts as NULL,
+// since ComputeAll stores empty string results as nil.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SQLRow is one exported row: column name -> text value (nil for NULL)
+type SQLRow map[string]*string
+
+// LoadSQLDump reads a CSV \copy export (header row, empty cell = NULL) or a
+// pg_dump COPY block (tab-separated, \N = NULL) of vw_language_candidates
+func LoadSQLDump(path string) ([]SQLRow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump: %w", err)
+	}
+	if bytes.Contains(data, []byte("FROM stdin;")) {
+		return parsePgDumpCopy(data)
+	}
+	return parseCopyCSV(data)
+}
+
+// parseCopyCSV parses \copy ... WITH CSV HEADER output
+func parseCopyCSV(data []byte) ([]SQLRow, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV dump: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV dump has no header row")
+	}
+
+	header := records[0]
+	rows := make([]SQLRow, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := SQLRow{}
+		for i, col := range header {
+			if i < len(record) && record[i] != "" {
+				v := record[i]
+				row[col] = &v
+			} else {
+				row[col] = nil
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parsePgDumpCopy parses the first COPY block that has a language_candidate_id column
+func parsePgDumpCopy(data []byte) ([]SQLRow, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var columns []string
+	var rows []SQLRow
+	for scanner.Scan() {
+		line := scanner.Text()
+		if columns == nil {
+			if !strings.HasPrefix(line, "COPY ") || !strings.HasSuffix(line, "FROM stdin;") {
+				continue
+			}
+			open, close := strings.Index(line, "("), strings.LastIndex(line, ")")
+			if open < 0 || close < open {
+				continue
+			}
+			cols := strings.Split(line[open+1:close], ",")
+			for i := range cols {
+				cols[i] = strings.Trim(strings.TrimSpace(cols[i]), `"`)
+			}
+			if slices.Contains(cols, "language_candidate_id") {
+				columns = cols
+			}
+			continue
+		}
+
+		if line == `\.` {
+			return rows, nil
+		}
+		values := strings.Split(line, "\t")
+		row := SQLRow{}
+		for i, col := range columns {
+			if i >= len(values) || values[i] == `\N` || values[i] == "" {
+				row[col] = nil
+				continue
+			}
+			v := unescapeCopyText(values[i])
+			row[col] = &v
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read pg_dump: %w", err)
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("pg_dump has no COPY block with language_candidate_id")
+	}
+	return nil, fmt.Errorf("pg_dump COPY block is not terminated by \\.")
+}
+
+// unescapeCopyText undoes COPY text-format backslash escapes
+func unescapeCopyText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r").Replace(s)
+}
+
+// sqlText renders a Go field value the way postgres exports it (nil for NULL or "")
+func sqlText(v any) *string {
+	var s string
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case string:
+		if t == "" {
+			return nil
+		}
+		s = t
+	case bool:
+		s = "f"
+		if t {
+			s = "t"
+		}
+	case int:
+		s = strconv.Itoa(t)
+	default:
+		s = fmt.Sprint(t)
+	}
+	return &s
+}
+
+// normalizeSQLBool maps postgres boolean spellings to t/f, leaving other text alone
+func normalizeSQLBool(s string) string {
+	switch strings.ToLower(s) {
+	case "t", "true":
+		return "t"
+	case "f", "false":
+		return "f"
+	}
+	return s
+}
+
+// CheckParityWithSQLDump compares computed candidates with a postgres export
+// of vw_language_candidates, matching rows by language_candidate_id. Every
+// column present in the dump must equal the Go value exactly (as SQL text).
+// Divergences read "<id>.<field>: go=<v> sql=<v>".
+func CheckParityWithSQLDump(candidates []LanguageCandidate, sqlDumpPath string) ([]string, error) {
+	rows, err := LoadSQLDump(sqlDumpPath)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]SQLRow, len(rows))
+	for _, row := range rows {
+		if id := row["language_candidate_id"]; id != nil {
+			byId[*id] = row
+		}
+	}
+
+	show := func(s *string) string {
+		if s == nil {
+			return "NULL"
+		}
+		return strconv.Quote(*s)
+	}
+
+	var diffs []string
+	for i := range candidates {
+		tc := &candidates[i]
+		row, ok := byId[tc.LanguageCandidateId]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: missing from dump", tc.LanguageCandidateId))
+			continue
+		}
+		delete(byId, tc.LanguageCandidateId)
+
+		for _, nv := range recordValues(tc) {
+			sqlValue, ok := row[nv.Name]
+			if !ok {
+				continue
+			}
+			goValue := sqlText(nv.Value)
+			if _, isBool := nv.Value.(bool); isBool && sqlValue != nil {
+				normalized := normalizeSQLBool(*sqlValue)
+				sqlValue = &normalized
+			}
+			if (goValue == nil) != (sqlValue == nil) || (goValue != nil && *goValue != *sqlValue) {
+				diffs = append(diffs, fmt.Sprintf("%s.%s: go=%s sql=%s", tc.LanguageCandidateId, nv.Name, show(goValue), show(sqlValue)))
+			}
+		}
+	}
+	for i := range rows {
+		if id := rows[i]["language_candidate_id"]; id != nil {
+			if _, extra := byId[*id]; extra {
+				diffs = append(diffs, fmt.Sprintf("%s: only in dump", *id))
+			}
+		}
+	}
+	return diffs, nil
+}
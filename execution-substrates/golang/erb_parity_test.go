@@ -0,0 +1,39 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// AssertParityWithSQLDump fails t with one error per divergence between
+// candidates and the postgres export at sqlDumpPath (see CheckParityWithSQLDump)
+func AssertParityWithSQLDump(t *testing.T, candidates []LanguageCandidate, sqlDumpPath string) {
+	t.Helper()
+	diffs, err := CheckParityWithSQLDump(candidates, sqlDumpPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range diffs {
+		t.Errorf("parity with %s: %s", sqlDumpPath, d)
+	}
+}
+
+func coffeeMug(t *testing.T) []LanguageCandidate {
+	return []LanguageCandidate{candidateByID(t, computedCandidates(t), "a-coffee-mug")}
+}
+
+func TestParityWithSQLDumpMatches(t *testing.T) {
+	AssertParityWithSQLDump(t, coffeeMug(t), filepath.Join("testdata", "parity", "match.csv"))
+}
+
+func TestParityWithSQLDumpReportsDivergentField(t *testing.T) {
+	diffs, err := CheckParityWithSQLDump(coffeeMug(t), filepath.Join("testdata", "parity", "divergent.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{`a-coffee-mug.predicted_answer: go="f" sql="t"`}
+	if !slices.Equal(diffs, want) {
+		t.Errorf("diffs = %q, want %q", diffs, want)
+	}
+}
@@ -0,0 +1,107 @@
+// ERB SDK - Prolog Export (hand-written)
+// ======================================
+// Renders the rulebook as a Prolog knowledge base: each candidate's true
+// booleans as facts, and PredictedAnswer as language/1 rules, so the data
+// can be loaded into a Prolog engine and queried (e.g. ?- language(X).).
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// prologPlainAtom matches atoms that need no quoting
+var prologPlainAtom = regexp.MustCompile(`^[a-z][a-zA-Z0-9_]*$`)
+
+// prologAtom renders s as a Prolog atom, quoting it when necessary
+func prologAtom(s string) string {
+	if prologPlainAtom.MatchString(s) {
+		return s
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`).Replace(s) + "'"
+}
+
+// hockettTraitFields are the json names of the traits summed by Bio_HockettScore
+var hockettTraitFields = []string{
+	"bio_has_semanticity", "bio_has_arbitrariness", "bio_has_discreteness",
+	"bio_has_duality_of_patterning", "bio_has_productivity", "bio_has_displacement",
+	"bio_has_cultural_transmission", "bio_has_interchangeability", "bio_has_feedback",
+	"bio_has_broadcast_transmission", "bio_has_rapid_fading",
+}
+
+// RenderPrologKB renders the rulebook's LanguageCandidates as Prolog facts
+// (candidate_name/2, one fact per true boolean raw field, and
+// is_description_of derived from the distance) followed by language/1, the
+// PredictedAnswer formula as rules. Negation is negation as failure, so a nil flag counts
+// as false, as in the generated code. Argument formalizations are included
+// as comments.
+func RenderPrologKB(rb *Rulebook) string {
+	var sb strings.Builder
+	sb.WriteString("% Generated from effortless-rulebook.json by RenderPrologKB\n\n")
+
+	// One predicate per boolean raw field, plus is_description_of derived
+	// from the distance, declared dynamic so queries never hit an existence
+	// error when no candidate has the flag
+	predicates := []string{"is_description_of"}
+	for name, f := range fieldsByJSONName(&LanguageCandidate{}) {
+		if f.Type() == reflect.TypeOf((*bool)(nil)) && slices.Contains(LanguageCandidateRawFields, name) {
+			predicates = append(predicates, name)
+		}
+	}
+	sort.Strings(predicates)
+	for _, p := range predicates {
+		fmt.Fprintf(&sb, ":- dynamic %s/1.\n", p)
+	}
+
+	sb.WriteString("\n")
+	for i := range rb.LanguageCandidates {
+		tc := &rb.LanguageCandidates[i]
+		if tc.Name != nil {
+			fmt.Fprintf(&sb, "candidate_name(%s, %s).\n", prologAtom(tc.LanguageCandidateId), prologAtom(*tc.Name))
+		}
+	}
+
+	// Facts grouped by predicate, as Prolog expects clauses to be contiguous
+	for _, p := range predicates {
+		sb.WriteString("\n")
+		for i := range rb.LanguageCandidates {
+			tc := &rb.LanguageCandidates[i]
+			var holds bool
+			if p == "is_description_of" {
+				holds = tc.DistanceFromConcept != nil && tc.CalcIsDescriptionOf()
+			} else {
+				holds, _ = derefValue(fieldsByJSONName(tc)[p]).(bool)
+			}
+			if holds {
+				fmt.Fprintf(&sb, "%s(%s).\n", p, prologAtom(tc.LanguageCandidateId))
+			}
+		}
+	}
+
+	sb.WriteString("\n% PredictedAnswer: all eight conditions hold...\n")
+	goals := make([]string, len(PredictionConditions))
+	for i, c := range PredictionConditions {
+		goals[i] = c.Field + "(X)"
+		if !c.Want {
+			goals[i] = `\+ ` + goals[i]
+		}
+	}
+	fmt.Fprintf(&sb, "language(X) :-\n    %s.\n", strings.Join(goals, ",\n    "))
+	sb.WriteString("% ...or the candidate shows at least one Hockett design feature\n")
+	for _, trait := range hockettTraitFields {
+		fmt.Fprintf(&sb, "language(X) :- %s(X).\n", trait)
+	}
+
+	sb.WriteString("\n% Argument formalizations (IsEverythingALanguage)\n")
+	for _, step := range rb.IsEverythingALanguage {
+		if f := stringVal(step.Formalization); f != "" {
+			fmt.Fprintf(&sb, "%% %s (%s): %s\n", step.IsEverythingALanguageId, stringVal(step.StepType), strings.ReplaceAll(f, "\n", " "))
+		}
+	}
+	return sb.String()
+}
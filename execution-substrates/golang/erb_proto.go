@@ -0,0 +1,139 @@
+// ERB SDK - Protocol Buffers Encoding (hand-written)
+// ==================================================
+// Binary wire format for the messages in the generated erb_sdk.proto,
+// without a protobuf dependency. Field numbers follow struct field order,
+// as the generator numbers them: bool and int fields are varints, string
+// fields are length-delimited, and nil pointers are omitted.
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+)
+
+// protobuf wire types used by erb_sdk.proto
+const (
+	protoVarint = 0
+	protoI64    = 1
+	protoBytes  = 2
+	protoI32    = 5
+)
+
+// MarshalCandidateViewProto encodes a (computed) LanguageCandidate as the
+// erb.LanguageCandidate message of erb_sdk.proto
+func MarshalCandidateViewProto(view LanguageCandidate) ([]byte, error) {
+	return marshalProto(&view)
+}
+
+// UnmarshalCandidateViewProto decodes an erb.LanguageCandidate message.
+// Unknown field numbers are skipped, as protobuf readers do.
+func UnmarshalCandidateViewProto(data []byte) (LanguageCandidate, error) {
+	var view LanguageCandidate
+	err := unmarshalProto(data, &view)
+	return view, err
+}
+
+// marshalProto encodes a pointer-to-struct record field by field
+func marshalProto(record any) ([]byte, error) {
+	v := reflect.ValueOf(record).Elem()
+	var buf []byte
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		number := uint64(i + 1)
+		if f.Kind() == reflect.Pointer {
+			if f.IsNil() {
+				continue
+			}
+			f = f.Elem()
+		} else if f.IsZero() {
+			continue // proto3 implicit presence
+		}
+
+		switch f.Kind() {
+		case reflect.Bool:
+			buf = binary.AppendUvarint(buf, number<<3|protoVarint)
+			if f.Bool() {
+				buf = append(buf, 1)
+			} else {
+				buf = append(buf, 0)
+			}
+		case reflect.Int:
+			buf = binary.AppendUvarint(buf, number<<3|protoVarint)
+			buf = binary.AppendUvarint(buf, uint64(f.Int()))
+		case reflect.String:
+			buf = binary.AppendUvarint(buf, number<<3|protoBytes)
+			buf = binary.AppendUvarint(buf, uint64(len(f.String())))
+			buf = append(buf, f.String()...)
+		default:
+			return nil, fmt.Errorf("field %s: unsupported kind %s", v.Type().Field(i).Name, f.Kind())
+		}
+	}
+	return buf, nil
+}
+
+// unmarshalProto decodes a message into a pointer-to-struct record
+func unmarshalProto(data []byte, record any) error {
+	v := reflect.ValueOf(record).Elem()
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("malformed field key")
+		}
+		data = data[n:]
+		number, wireType := key>>3, key&7
+
+		var raw uint64
+		var bytes []byte
+		switch wireType {
+		case protoVarint:
+			if raw, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("field %d: malformed varint", number)
+			}
+			data = data[n:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("field %d: malformed length", number)
+			}
+			bytes, data = data[n:n+int(length)], data[n+int(length):]
+		case protoI64, protoI32:
+			size := 8
+			if wireType == protoI32 {
+				size = 4
+			}
+			if len(data) < size {
+				return fmt.Errorf("field %d: truncated fixed-width value", number)
+			}
+			data = data[size:]
+			continue
+		default:
+			return fmt.Errorf("field %d: unsupported wire type %d", number, wireType)
+		}
+
+		if number == 0 || number > uint64(v.NumField()) {
+			continue
+		}
+		f := v.Field(int(number - 1))
+		target := f
+		if f.Kind() == reflect.Pointer {
+			target = reflect.New(f.Type().Elem()).Elem()
+		}
+
+		switch {
+		case target.Kind() == reflect.Bool && wireType == protoVarint:
+			target.SetBool(raw != 0)
+		case target.Kind() == reflect.Int && wireType == protoVarint:
+			target.SetInt(int64(raw))
+		case target.Kind() == reflect.String && wireType == protoBytes:
+			target.SetString(string(bytes))
+		default:
+			return fmt.Errorf("field %d: wire type %d does not match %s", number, wireType, target.Kind())
+		}
+		if f.Kind() == reflect.Pointer {
+			f.Set(target.Addr())
+		}
+	}
+	return nil
+}
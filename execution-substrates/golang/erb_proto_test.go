@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// protoCandidates are every computed rulebook candidate plus one with
+// every nullable field nil, to cover omitted fields
+func protoCandidates(t *testing.T) []LanguageCandidate {
+	return append(computedCandidates(t), LanguageCandidate{LanguageCandidateId: "all-nil"})
+}
+
+func TestCandidateViewProtoRoundTrip(t *testing.T) {
+	for _, want := range protoCandidates(t) {
+		data, err := MarshalCandidateViewProto(want)
+		if err != nil {
+			t.Fatalf("%s: %v", want.LanguageCandidateId, err)
+		}
+		got, err := UnmarshalCandidateViewProto(data)
+		if err != nil {
+			t.Fatalf("%s: %v", want.LanguageCandidateId, err)
+		}
+		for _, p := range CompareCandidates(want, got) {
+			if p.Differs {
+				t.Errorf("%s.%s: encoded %s, decoded %s", want.LanguageCandidateId, p.Field, displayValue(p.A), displayValue(p.B))
+			}
+		}
+	}
+}
+
+func TestProtoSerializerWritesDelimitedMessages(t *testing.T) {
+	views := protoCandidates(t)
+	path := filepath.Join(t.TempDir(), "answers.pb")
+	if err := SaveWith(path, views, ProtoSerializer{}); err != nil {
+		t.Fatal(err)
+	}
+	data := readFile(t, path)
+
+	var decoded []LanguageCandidate
+	for len(data) > 0 {
+		n, k := binary.Uvarint(data)
+		if k <= 0 || uint64(len(data)-k) < n {
+			t.Fatalf("bad length prefix after %d messages", len(decoded))
+		}
+		view, err := UnmarshalCandidateViewProto(data[k : k+int(n)])
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, view)
+		data = data[k+int(n):]
+	}
+	if !reflect.DeepEqual(decoded, views) {
+		t.Errorf("decoded %d messages that differ from the %d saved", len(decoded), len(views))
+	}
+}
@@ -0,0 +1,190 @@
+// ERB SDK - Cross-Substrate Reconciliation (hand-written)
+// =======================================================
+// Compares test-answers produced by other execution substrates
+// (../<substrate>/test-answers/*.json) with each other. Those files are
+// read as generic JSON rows, since not every substrate encodes values
+// exactly as the Go structs do.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// AnswerRow is one record of a substrate's test-answers file
+type AnswerRow map[string]any
+
+// LoadAnswerRows loads a test-answers JSON array without a typed struct
+func LoadAnswerRows(path string) ([]AnswerRow, error) {
+	return LoadRecords[AnswerRow](path)
+}
+
+// AnswerRowsOf converts typed records (e.g. computed LanguageCandidates)
+// to AnswerRows through their JSON encoding, as if saved and reloaded
+func AnswerRowsOf(records any) ([]AnswerRow, error) {
+	data, err := json.Marshal(records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal records: %w", err)
+	}
+	var rows []AnswerRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse records: %w", err)
+	}
+	return rows, nil
+}
+
+// CoerceHasGrammar maps a has_grammar value to its canonical boolean and
+// names the representation it arrived in ("bool", "string" or "null").
+// Strings follow the CAST(... AS TEXT) encoding: "" and "false" are false.
+// The canonical value is the one this substrate computes: CalcHasGrammar
+// and ComputeAll both give a boolean, false when HasSyntax is nil.
+func CoerceHasGrammar(v any) (bool, string, error) {
+	switch t := v.(type) {
+	case nil:
+		return false, "null", nil
+	case bool:
+		return t, "bool", nil
+	case string:
+		switch strings.ToLower(strings.TrimSpace(t)) {
+		case "true", "t":
+			return true, "string", nil
+		case "", "false", "f":
+			return false, "string", nil
+		}
+		return false, "string", fmt.Errorf("unrecognized has_grammar string %q", t)
+	}
+	return false, "", fmt.Errorf("unsupported has_grammar value %v (%T)", v, v)
+}
+
+// HasGrammarReconciliation is the result of comparing has_grammar across substrates
+type HasGrammarReconciliation struct {
+	// Disagreements lists candidate ids whose canonical has_grammar differs between substrates
+	Disagreements []string `json:"disagreements"`
+	// Representations maps each substrate to the representations it used
+	Representations map[string][]string `json:"representations"`
+	// MixedRepresentations is true when substrates did not all use the same representation
+	MixedRepresentations bool `json:"mixed_representations"`
+}
+
+// ReconcileHasGrammar compares has_grammar across substrates after coercing
+// every value to a canonical boolean, so "true"/true or ""/false are not
+// reported as differences
+func ReconcileHasGrammar(substrates map[string][]AnswerRow) (HasGrammarReconciliation, error) {
+	result := HasGrammarReconciliation{Representations: map[string][]string{}}
+	canonical := map[string]map[bool]bool{} // candidate id -> set of values seen
+	allReps := map[string]bool{}
+
+	names := make([]string, 0, len(substrates))
+	for name := range substrates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		reps := map[string]bool{}
+		for _, row := range substrates[name] {
+			id := fmt.Sprint(row["language_candidate_id"])
+			value, rep, err := CoerceHasGrammar(row["has_grammar"])
+			if err != nil {
+				return result, fmt.Errorf("%s/%s: %w", name, id, err)
+			}
+			reps[rep] = true
+			allReps[rep] = true
+			if canonical[id] == nil {
+				canonical[id] = map[bool]bool{}
+			}
+			canonical[id][value] = true
+		}
+		for rep := range reps {
+			result.Representations[name] = append(result.Representations[name], rep)
+		}
+		sort.Strings(result.Representations[name])
+	}
+
+	for id, values := range canonical {
+		if len(values) > 1 {
+			result.Disagreements = append(result.Disagreements, id)
+		}
+	}
+	sort.Strings(result.Disagreements)
+	result.MixedRepresentations = len(allReps) > 1
+	return result, nil
+}
+
+// AnswerDiff is one disagreement between two test-answers files: a field
+// whose values differ, or (Field empty) a record only one side has
+type AnswerDiff struct {
+	LanguageCandidateId string `json:"language_candidate_id"`
+	Field               string `json:"field,omitempty"`
+	A                   any    `json:"a"`
+	B                   any    `json:"b"`
+	Missing             string `json:"missing,omitempty"` // "a" or "b" when the record is absent there
+}
+
+// DiffAnswers compares two substrates' test-answers record by record,
+// matching records on language_candidate_id. Fields are compared as
+// decoded JSON, with an absent key equal to null. Diffs follow a's record
+// order, then the records only b has, each record's fields sorted by name.
+func DiffAnswers(a, b []AnswerRow) ([]AnswerDiff, error) {
+	byID := func(side string, rows []AnswerRow) (map[string]AnswerRow, []string, error) {
+		index := make(map[string]AnswerRow, len(rows))
+		ids := make([]string, 0, len(rows))
+		for i, row := range rows {
+			id, ok := row["language_candidate_id"].(string)
+			if !ok || id == "" {
+				return nil, nil, fmt.Errorf("%s: record %d has no language_candidate_id", side, i)
+			}
+			if _, dup := index[id]; dup {
+				return nil, nil, fmt.Errorf("%s: language_candidate_id %q appears more than once", side, id)
+			}
+			index[id] = row
+			ids = append(ids, id)
+		}
+		return index, ids, nil
+	}
+	aByID, aIDs, err := byID("a", a)
+	if err != nil {
+		return nil, err
+	}
+	bByID, bIDs, err := byID("b", b)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []AnswerDiff
+	for _, id := range aIDs {
+		bRow, ok := bByID[id]
+		if !ok {
+			diffs = append(diffs, AnswerDiff{LanguageCandidateId: id, Missing: "b"})
+			continue
+		}
+		aRow := aByID[id]
+		fields := map[string]bool{}
+		for k := range aRow {
+			fields[k] = true
+		}
+		for k := range bRow {
+			fields[k] = true
+		}
+		names := make([]string, 0, len(fields))
+		for k := range fields {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !reflect.DeepEqual(aRow[name], bRow[name]) {
+				diffs = append(diffs, AnswerDiff{LanguageCandidateId: id, Field: name, A: aRow[name], B: bRow[name]})
+			}
+		}
+	}
+	for _, id := range bIDs {
+		if _, ok := aByID[id]; !ok {
+			diffs = append(diffs, AnswerDiff{LanguageCandidateId: id, Missing: "a"})
+		}
+	}
+	return diffs, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReconcileHasGrammar(t *testing.T) {
+	substrates := map[string][]AnswerRow{
+		"golang": {
+			{"language_candidate_id": "python", "has_grammar": true},
+			{"language_candidate_id": "a-rock", "has_grammar": false},
+			{"language_candidate_id": "math", "has_grammar": true},
+		},
+		"postgres": {
+			{"language_candidate_id": "python", "has_grammar": "true"},
+			{"language_candidate_id": "a-rock", "has_grammar": ""},
+			{"language_candidate_id": "math", "has_grammar": nil},
+		},
+	}
+
+	got, err := ReconcileHasGrammar(substrates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HasGrammarReconciliation{
+		Disagreements: []string{"math"},
+		Representations: map[string][]string{
+			"golang":   {"bool"},
+			"postgres": {"null", "string"},
+		},
+		MixedRepresentations: true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReconcileHasGrammar = %+v, want %+v", got, want)
+	}
+
+	substrates["postgres"][0]["has_grammar"] = "maybe"
+	if _, err := ReconcileHasGrammar(substrates); err == nil {
+		t.Error("ReconcileHasGrammar with has_grammar \"maybe\" = nil, want an error")
+	}
+}
@@ -0,0 +1,286 @@
+// ERB SDK - Review Helpers (hand-written)
+// =======================================
+// Functions that turn computed LanguageCandidates into work lists for
+// human reviewers of the rulebook data.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// QueueEntry is one candidate in the human review queue
+type QueueEntry struct {
+	LanguageCandidateId string  `json:"language_candidate_id"`
+	Name                *string `json:"name"`
+	IsMismatch          bool    `json:"is_mismatch"`
+	PredictionScore     int     `json:"prediction_score"`
+	Coverage            float64 `json:"coverage"`
+}
+
+// ReviewQueue orders candidates for human review: mismatches first, then by
+// how many PredictionConditions they satisfy (closest calls first), then by
+// raw-field coverage (most complete first). Ties keep id order.
+func ReviewQueue(candidates []LanguageCandidate) []QueueEntry {
+	queue := make([]QueueEntry, 0, len(candidates))
+	for i := range candidates {
+		tc := &candidates[i]
+		queue = append(queue, QueueEntry{
+			LanguageCandidateId: tc.LanguageCandidateId,
+			Name:                tc.Name,
+			IsMismatch:          tc.IsMismatch(),
+			PredictionScore:     tc.PredictionScore(),
+			Coverage:            fieldCoverage(tc, LanguageCandidateRawFields),
+		})
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		a, b := queue[i], queue[j]
+		if a.IsMismatch != b.IsMismatch {
+			return a.IsMismatch
+		}
+		if a.PredictionScore != b.PredictionScore {
+			return a.PredictionScore > b.PredictionScore
+		}
+		if a.Coverage != b.Coverage {
+			return a.Coverage > b.Coverage
+		}
+		return a.LanguageCandidateId < b.LanguageCandidateId
+	})
+	return queue
+}
+
+// Assertion is a named business rule every computed candidate must satisfy
+type Assertion struct {
+	Name  string
+	Check func(tc *LanguageCandidate) bool
+}
+
+// AssertionResult reports one Assertion over a set of computed candidates
+type AssertionResult struct {
+	Name       string   `json:"name"`
+	Passed     bool     `json:"passed"`
+	FailingIds []string `json:"failing_ids"`
+}
+
+// DefaultAssertions are the data-quality rules the rulebook is expected to meet
+var DefaultAssertions = []Assertion{
+	{Name: "candidates marked as languages are predicted languages", Check: func(tc *LanguageCandidate) bool {
+		return !boolVal(tc.IsLanguage) || boolVal(tc.PredictedAnswer)
+	}},
+	{Name: "no candidate is both open world and closed world", Check: func(tc *LanguageCandidate) bool {
+		return !boolVal(tc.IsOpenClosedWorldConflicted)
+	}},
+}
+
+// RunAssertions checks each assertion against every computed candidate
+func RunAssertions(views []LanguageCandidate, assertions []Assertion) []AssertionResult {
+	results := make([]AssertionResult, 0, len(assertions))
+	for _, a := range assertions {
+		result := AssertionResult{Name: a.Name, Passed: true}
+		for i := range views {
+			if !a.Check(&views[i]) {
+				result.Passed = false
+				result.FailingIds = append(result.FailingIds, views[i].LanguageCandidateId)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// GateAgainstBaseline loads a baseline of computed candidates and fails when
+// more than maxRegressions candidates that agreed with IsLanguage in the
+// baseline are now mismatches. Agreement is read from the baseline's stored
+// predicted_answer, not recomputed; a null predicted_answer never agreed.
+func GateAgainstBaseline(current []LanguageCandidate, baselinePath string, maxRegressions int) error {
+	baseline, err := LoadLanguageCandidateRecords(baselinePath)
+	if err != nil {
+		return fmt.Errorf("failed to load baseline: %w", err)
+	}
+
+	agreed := make(map[string]bool, len(baseline))
+	for i := range baseline {
+		b := &baseline[i]
+		agreed[b.LanguageCandidateId] = b.PredictedAnswer != nil && *b.PredictedAnswer == boolVal(b.IsLanguage)
+	}
+
+	var regressions []string
+	for i := range current {
+		id := current[i].LanguageCandidateId
+		if agreed[id] && current[i].IsMismatch() {
+			regressions = append(regressions, id)
+		}
+	}
+
+	if len(regressions) > maxRegressions {
+		return fmt.Errorf("%d regressions against baseline (max %d): %v", len(regressions), maxRegressions, regressions)
+	}
+	return nil
+}
+
+// SuspectNameCategoryOverlap flags candidates whose Name and Category are
+// equal or contain one another (case-insensitive), a likely sign that one
+// was pasted into the other. Entries read "<id>: <description>".
+func SuspectNameCategoryOverlap(rb *Rulebook) []string {
+	var suspects []string
+	for i := range rb.LanguageCandidates {
+		tc := &rb.LanguageCandidates[i]
+		name := strings.ToLower(strings.TrimSpace(stringVal(tc.Name)))
+		category := strings.ToLower(strings.TrimSpace(stringVal(tc.Category)))
+		if name == "" || category == "" {
+			continue
+		}
+
+		var overlap string
+		switch {
+		case name == category:
+			overlap = "name equals category"
+		case strings.Contains(name, category):
+			overlap = "name contains category"
+		case strings.Contains(category, name):
+			overlap = "category contains name"
+		default:
+			continue
+		}
+		suspects = append(suspects, fmt.Sprintf("%s: %s (%q / %q)", tc.LanguageCandidateId, overlap, stringVal(tc.Name), stringVal(tc.Category)))
+	}
+	return suspects
+}
+
+// RelationshipDistanceMismatches returns the ids of candidates that carry a
+// precomputed RelationshipToConcept disagreeing with the one derived from
+// DistanceFromConcept. Candidates without a relationship are skipped.
+func RelationshipDistanceMismatches(candidates []LanguageCandidate) []string {
+	var ids []string
+	for i := range candidates {
+		tc := &candidates[i]
+		if tc.RelationshipToConcept != nil && *tc.RelationshipToConcept != tc.CalcRelationshipToConcept() {
+			ids = append(ids, tc.LanguageCandidateId)
+		}
+	}
+	return ids
+}
+
+// FindHeldLanguageCategories returns the ids of candidates whose category
+// mentions "language" (case-insensitively) but which can be held, e.g. a
+// sign language prop: a physical object filed under a language category.
+func FindHeldLanguageCategories(candidates []LanguageCandidate) []string {
+	var ids []string
+	for i := range candidates {
+		tc := &candidates[i]
+		if boolVal(tc.CanBeHeld) && strings.Contains(strings.ToLower(stringVal(tc.Category)), "language") {
+			ids = append(ids, tc.LanguageCandidateId)
+		}
+	}
+	return ids
+}
+
+// Contradictions returns descriptions of the raw flags that contradict the
+// candidate being a language: the negated PredictionConditions that are
+// true (e.g. "can_be_held is true"), and open world together with closed
+// world. Nil flags never contradict.
+func (tc *LanguageCandidate) Contradictions() []string {
+	var contradictions []string
+	for _, c := range PredictionConditions {
+		if v := c.value(tc); !c.Want && v != nil && *v {
+			contradictions = append(contradictions, c.Field+" is true")
+		}
+	}
+	if boolVal(tc.IsOpenWorld) && boolVal(tc.IsClosedWorld) {
+		contradictions = append(contradictions, "is_open_world and is_closed_world are both true")
+	}
+	return contradictions
+}
+
+// Anomaly is a candidate together with the contradictions found in its data
+type Anomaly struct {
+	LanguageCandidateId string   `json:"language_candidate_id"`
+	Name                *string  `json:"name"`
+	Contradictions      []string `json:"contradictions"`
+}
+
+// ChosenWithContradictions returns the candidates marked IsLanguage whose
+// raw flags contradict that choice, the riskiest rows in the rulebook
+func ChosenWithContradictions(rb *Rulebook) []Anomaly {
+	var anomalies []Anomaly
+	for i := range rb.LanguageCandidates {
+		tc := &rb.LanguageCandidates[i]
+		if !boolVal(tc.IsLanguage) {
+			continue
+		}
+		if contradictions := tc.Contradictions(); len(contradictions) > 0 {
+			anomalies = append(anomalies, Anomaly{
+				LanguageCandidateId: tc.LanguageCandidateId,
+				Name:                tc.Name,
+				Contradictions:      contradictions,
+			})
+		}
+	}
+	return anomalies
+}
+
+// NearMiss is a candidate one condition away from being a top answer
+type NearMiss struct {
+	LanguageCandidateId string  `json:"language_candidate_id"`
+	Name                *string `json:"name"`
+	Condition           string  `json:"condition"`    // json name of the failing condition
+	NeededValue         bool    `json:"needed_value"` // value that field needs
+}
+
+// NearMisses returns the rulebook's candidates that are not top answers
+// (PredictedAnswer false) and fail exactly one PredictionCondition, the
+// close calls most worth a second look. Satisfying that one condition
+// would make them top answers, since their Hockett branch is already false.
+func NearMisses(rb *Rulebook) []NearMiss {
+	var misses []NearMiss
+	for i := range rb.LanguageCandidates {
+		tc := rb.LanguageCandidates[i].ComputeAll()
+		if boolVal(tc.PredictedAnswer) {
+			continue
+		}
+		var failing []PredictionCondition
+		for _, c := range PredictionConditions {
+			if !c.Holds(tc) {
+				failing = append(failing, c)
+			}
+		}
+		if len(failing) != 1 {
+			continue
+		}
+		misses = append(misses, NearMiss{
+			LanguageCandidateId: tc.LanguageCandidateId,
+			Name:                tc.Name,
+			Condition:           failing[0].Field,
+			NeededValue:         failing[0].Want,
+		})
+	}
+	return misses
+}
+
+// FieldPair is one field of two candidates side by side
+type FieldPair struct {
+	Field   string
+	A, B    any // dereferenced values, nil when null
+	Differs bool
+}
+
+// CompareCandidates pairs every raw and calculated field of two computed
+// candidates in struct order, marking the fields whose values differ, so a
+// reviewer can see which field split two similar candidates' verdicts
+func CompareCandidates(a, b LanguageCandidate) []FieldPair {
+	av, bv := recordValues(&a), recordValues(&b)
+	pairs := make([]FieldPair, len(av))
+	for i := range av {
+		pairs[i] = FieldPair{
+			Field:   av[i].Name,
+			A:       av[i].Value,
+			B:       bv[i].Value,
+			Differs: !reflect.DeepEqual(av[i].Value, bv[i].Value),
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReviewQueueOrder(t *testing.T) {
+	candidate := func(id string, isLanguage bool, edit func(tc *LanguageCandidate)) LanguageCandidate {
+		tc := topAnswer()
+		tc.LanguageCandidateId = id
+		tc.IsLanguage = ptr(isLanguage)
+		edit(&tc)
+		return tc
+	}
+	candidates := []LanguageCandidate{
+		candidate("far", false, func(tc *LanguageCandidate) { tc.HasSyntax, tc.IsParsed = ptr(false), ptr(false) }),
+		candidate("close", false, func(tc *LanguageCandidate) { tc.CanBeHeld = ptr(true) }),
+		candidate("agree", true, func(tc *LanguageCandidate) {}),
+		candidate("close-full", false, func(tc *LanguageCandidate) {
+			tc.CanBeHeld = ptr(true)
+			tc.Name, tc.Category = ptr("Close"), ptr("Example")
+		}),
+		candidate("mismatch", false, func(tc *LanguageCandidate) {}),
+	}
+
+	queue := ReviewQueue(candidates)
+	var ids []string
+	for _, e := range queue {
+		ids = append(ids, e.LanguageCandidateId)
+	}
+	want := []string{"mismatch", "agree", "close-full", "close", "far"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("ReviewQueue order = %v, want %v", ids, want)
+	}
+	if !queue[0].IsMismatch || queue[1].IsMismatch {
+		t.Errorf("IsMismatch = %v, %v, want true, false", queue[0].IsMismatch, queue[1].IsMismatch)
+	}
+	if queue[2].PredictionScore != 7 || queue[4].PredictionScore != 6 {
+		t.Errorf("PredictionScore = %d, %d, want 7, 6", queue[2].PredictionScore, queue[4].PredictionScore)
+	}
+	if queue[2].Coverage <= queue[3].Coverage {
+		t.Errorf("Coverage %v <= %v, want the fuller record first", queue[2].Coverage, queue[3].Coverage)
+	}
+}
+
+func TestGateAgainstBaselineUsesStoredPredictedAnswer(t *testing.T) {
+	// The baseline stored predicted_answer=true for a mug marked as a
+	// language, so it agreed then, although recomputing its raw fields
+	// today gives false
+	mug := candidateByID(t, computedCandidates(t), "a-coffee-mug")
+	mug.IsLanguage = ptr(true)
+	stored := mug
+	stored.PredictedAnswer = ptr(true)
+
+	baselinePath := filepath.Join(t.TempDir(), "baseline.json")
+	if err := SaveLanguageCandidateRecords(baselinePath, []LanguageCandidate{stored}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := GateAgainstBaseline([]LanguageCandidate{mug}, baselinePath, 0); err == nil {
+		t.Error("GateAgainstBaseline = nil, want a regression for a-coffee-mug")
+	}
+	if err := GateAgainstBaseline([]LanguageCandidate{mug}, baselinePath, 1); err != nil {
+		t.Errorf("GateAgainstBaseline with maxRegressions 1 = %v, want nil", err)
+	}
+
+	// A stored mismatch (or a null predicted_answer) cannot regress
+	stored.PredictedAnswer = ptr(false)
+	if err := SaveLanguageCandidateRecords(baselinePath, []LanguageCandidate{stored}); err != nil {
+		t.Fatal(err)
+	}
+	if err := GateAgainstBaseline([]LanguageCandidate{mug}, baselinePath, 0); err != nil {
+		t.Errorf("GateAgainstBaseline after a stored mismatch = %v, want nil", err)
+	}
+	stored.PredictedAnswer = nil
+	if err := SaveLanguageCandidateRecords(baselinePath, []LanguageCandidate{stored}); err != nil {
+		t.Fatal(err)
+	}
+	if err := GateAgainstBaseline([]LanguageCandidate{mug}, baselinePath, 0); err != nil {
+		t.Errorf("GateAgainstBaseline after a null predicted_answer = %v, want nil", err)
+	}
+}
+
+func TestRunAssertions(t *testing.T) {
+	views := computedCandidates(t)
+	assertions := []Assertion{
+		{Name: "every candidate has an id", Check: func(tc *LanguageCandidate) bool { return tc.LanguageCandidateId != "" }},
+		{Name: "nothing can be held", Check: func(tc *LanguageCandidate) bool { return !boolVal(tc.CanBeHeld) }},
+	}
+
+	results := RunAssertions(views, assertions)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if !results[0].Passed || len(results[0].FailingIds) > 0 {
+		t.Errorf("%s = %+v, want passed", results[0].Name, results[0])
+	}
+	if results[1].Passed || !slices.Contains(results[1].FailingIds, "a-coffee-mug") || slices.Contains(results[1].FailingIds, "python") {
+		t.Errorf("%s = %+v, want failed on a-coffee-mug but not python", results[1].Name, results[1])
+	}
+
+	// The rulebook's falsifier rows exist to break the default rules
+	want := [][]string{{"falsifier-a"}, {"owa-cwa-falsifier"}}
+	for i, r := range RunAssertions(views, DefaultAssertions) {
+		if r.Passed || !slices.Equal(r.FailingIds, want[i]) {
+			t.Errorf("default assertion %q failing ids = %v, want %v", r.Name, r.FailingIds, want[i])
+		}
+	}
+}
+
+func TestFindHeldLanguageCategories(t *testing.T) {
+	candidates := []LanguageCandidate{
+		{LanguageCandidateId: "asl-flashcards", Category: ptr("Sign Language prop"), CanBeHeld: ptr(true)},
+		{LanguageCandidateId: "sign-language", Category: ptr("Natural Language"), CanBeHeld: ptr(false)},
+		{LanguageCandidateId: "a-coffee-mug", Category: ptr("Physical Object"), CanBeHeld: ptr(true)},
+		{LanguageCandidateId: "unknown", Category: ptr("language"), CanBeHeld: nil},
+	}
+	if got := FindHeldLanguageCategories(candidates); !slices.Equal(got, []string{"asl-flashcards"}) {
+		t.Errorf("FindHeldLanguageCategories = %v, want [asl-flashcards]", got)
+	}
+}
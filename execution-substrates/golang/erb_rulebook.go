@@ -0,0 +1,470 @@
+// ERB SDK - Rulebook Loader (hand-written)
+// ========================================
+// Loads effortless-rulebook.json directly, so the SDK can work from the
+// source of truth rather than only from the shared blank tests.
+//
+// Rulebook rows are keyed by schema field names (PascalCase), which are also
+// the generated Go field names, so rows are decoded field-by-field rather
+// than through the snake_case json tags.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRulebookPath is the rulebook location relative to this substrate directory
+var DefaultRulebookPath = filepath.Join("..", "..", "effortless-rulebook", "effortless-rulebook.json")
+
+// FieldDef is one field of a table schema in the rulebook
+type FieldDef struct {
+	Name        string `json:"name"`
+	Datatype    string `json:"datatype"`
+	Type        string `json:"type"` // "raw" or "calculated"
+	Nullable    bool   `json:"nullable"`
+	Formula     string `json:"formula,omitempty"`
+	Description string `json:"Description,omitempty"`
+}
+
+// RulebookTable is one table of the rulebook: its schema and data rows
+type RulebookTable struct {
+	Description string                       `json:"Description"`
+	Schema      []FieldDef                   `json:"schema"`
+	Data        []map[string]json.RawMessage `json:"data"`
+}
+
+// Rulebook is a parsed effortless-rulebook.json
+type Rulebook struct {
+	Name        string
+	Description string
+	TableNames  []string // in declaration order
+	Tables      map[string]*RulebookTable
+
+	LanguageCandidates    []LanguageCandidate
+	IsEverythingALanguage []IsEverythingALanguage
+	ERBCustomizations     []ERBCustomization
+}
+
+// LoadFromRulebook loads and decodes a rulebook JSON file
+func LoadFromRulebook(path string) (*Rulebook, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rulebook: %w", err)
+	}
+	defer f.Close()
+
+	rb, err := DecodeRulebook(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rb, nil
+}
+
+// LoadFromRulebookStrict is LoadFromRulebook that rejects data row keys
+// with no generated struct field, naming the key and its table, so a
+// renamed schema field fails loudly instead of silently loading as null
+func LoadFromRulebookStrict(path string) (*Rulebook, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rulebook: %w", err)
+	}
+	defer f.Close()
+
+	rb, err := decodeRulebook(f, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rb, nil
+}
+
+// RulebookFetchTimeout bounds LoadFromRulebookURL, on top of its context
+var RulebookFetchTimeout = 30 * time.Second
+
+// RulebookMaxBytes is the largest response body LoadFromRulebookURL reads
+var RulebookMaxBytes int64 = 64 << 20
+
+// LoadFromRulebookURL fetches a rulebook with an HTTP GET and decodes it.
+// Non-200 responses are errors, as are content types other than JSON or
+// text/plain (which raw file hosts commonly serve), and a body larger than
+// RulebookMaxBytes is a *LimitError.
+func LoadFromRulebookURL(ctx context.Context, url string) (*Rulebook, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rulebook: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := (&http.Client{Timeout: RulebookFetchTimeout}).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rulebook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch rulebook: %s: %s", url, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || (mediaType != "application/json" && mediaType != "text/plain" && !strings.HasSuffix(mediaType, "+json")) {
+			return nil, fmt.Errorf("failed to fetch rulebook: %s: unexpected content type %q", url, ct)
+		}
+	}
+
+	data, err := readLimited(resp.Body, RulebookMaxBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rulebook: %s: %w", url, err)
+	}
+	rb, err := DecodeRulebook(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	return rb, nil
+}
+
+// DecodeRulebook decodes a rulebook from r
+func DecodeRulebook(r io.Reader) (*Rulebook, error) {
+	return decodeRulebook(r, false)
+}
+
+// decodeRulebook decodes a rulebook, in strict mode rejecting row keys the
+// generated structs lack
+func decodeRulebook(r io.Reader, strict bool) (*Rulebook, error) {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("failed to parse rulebook: expected a JSON object")
+	}
+
+	rb := &Rulebook{Tables: map[string]*RulebookTable{}}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse rulebook: %w", err)
+		}
+		key := tok.(string)
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("failed to parse rulebook key %q: %w", key, err)
+		}
+
+		switch key {
+		case "Name":
+			if err := json.Unmarshal(raw, &rb.Name); err != nil {
+				return nil, fmt.Errorf("failed to parse rulebook Name: %w", err)
+			}
+			continue
+		case "Description":
+			if err := json.Unmarshal(raw, &rb.Description); err != nil {
+				return nil, fmt.Errorf("failed to parse rulebook Description: %w", err)
+			}
+			continue
+		}
+
+		// Tables are the objects carrying a schema; everything else is metadata
+		if !bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{")) {
+			continue
+		}
+		var table RulebookTable
+		if err := json.Unmarshal(raw, &table); err != nil {
+			return nil, fmt.Errorf("failed to parse table %s: %w", key, err)
+		}
+		if table.Schema == nil {
+			continue
+		}
+		rb.TableNames = append(rb.TableNames, key)
+		rb.Tables[key] = &table
+	}
+
+	if err := rb.decodeTables(strict); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+// decodeTables fills the typed slices for the tables that have generated structs
+func (rb *Rulebook) decodeTables(strict bool) error {
+	targets := map[string]any{
+		"LanguageCandidates":    &rb.LanguageCandidates,
+		"IsEverythingALanguage": &rb.IsEverythingALanguage,
+		"ERBCustomizations":     &rb.ERBCustomizations,
+	}
+	for name, target := range targets {
+		table, ok := rb.Tables[name]
+		if !ok {
+			continue
+		}
+		slice := reflect.ValueOf(target).Elem()
+		for i, row := range table.Data {
+			record := reflect.New(slice.Type().Elem())
+			if strict {
+				var unknown []string
+				for key := range row {
+					if !record.Elem().FieldByName(key).IsValid() {
+						unknown = append(unknown, strconv.Quote(key))
+					}
+				}
+				if len(unknown) > 0 {
+					sort.Strings(unknown)
+					return fmt.Errorf("%s row %d: unknown field %s", name, i, strings.Join(unknown, ", "))
+				}
+			}
+			if err := decodeRulebookRow(row, record.Interface()); err != nil {
+				return fmt.Errorf("%s row %d: %w", name, i, err)
+			}
+			slice.Set(reflect.Append(slice, record.Elem()))
+		}
+	}
+	return nil
+}
+
+// decodeRulebookRow decodes a row keyed by schema field names into a generated struct.
+// Keys without a matching struct field are ignored.
+func decodeRulebookRow(row map[string]json.RawMessage, record any) error {
+	v := reflect.ValueOf(record).Elem()
+	for key, value := range row {
+		field := v.FieldByName(key)
+		if !field.IsValid() {
+			continue
+		}
+		if err := json.Unmarshal(value, field.Addr().Interface()); err != nil {
+			return fmt.Errorf("field %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// ExpectedTables returns the rulebook's tables that have calculated fields,
+// in declaration order: the tables the generated runner should process
+func ExpectedTables(rb *Rulebook) []string {
+	var tables []string
+	for _, name := range rb.TableNames {
+		for _, f := range rb.Tables[name].Schema {
+			if f.Type == "calculated" {
+				tables = append(tables, name)
+				break
+			}
+		}
+	}
+	return tables
+}
+
+// RulebookFieldOrder returns the json names of table's fields in the order
+// the rulebook schema declares them, the canonical column order for exports.
+// Schema fields the generated struct lacks are skipped.
+func RulebookFieldOrder(rb *Rulebook, table string) ([]string, error) {
+	t, ok := rb.Tables[table]
+	if !ok {
+		return nil, fmt.Errorf("rulebook has no table %q", table)
+	}
+	if _, ok := rulebookRecordTypes[table]; !ok {
+		return nil, fmt.Errorf("table %q has no generated struct", table)
+	}
+	return schemaJSONNames(table, t.Schema), nil
+}
+
+// rulebookRecordTypes maps table names to their generated structs
+var rulebookRecordTypes = map[string]reflect.Type{
+	"LanguageCandidates":    reflect.TypeOf(LanguageCandidate{}),
+	"IsEverythingALanguage": reflect.TypeOf(IsEverythingALanguage{}),
+	"ERBCustomizations":     reflect.TypeOf(ERBCustomization{}),
+}
+
+// schemaJSONNames returns the json names of the schema fields, in order,
+// skipping those table's generated struct lacks
+func schemaJSONNames(table string, schema []FieldDef) []string {
+	rt, ok := rulebookRecordTypes[table]
+	if !ok {
+		return nil
+	}
+	fields := make([]string, 0, len(schema))
+	for _, f := range schema {
+		sf, ok := rt.FieldByName(f.Name)
+		if !ok {
+			continue
+		}
+		if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// TableRow is one data row of any rulebook table, generated struct or not.
+// Values is keyed by schema field name (numbers integral where possible);
+// Schema is the table's field list, shared by all its rows.
+type TableRow struct {
+	Schema []FieldDef
+	Values map[string]any
+}
+
+// All yields the row's schema fields and their values in schema order, so
+// callers can tell raw from calculated fields by FieldDef.Type. Fields the
+// row lacks yield nil; keys outside the schema are skipped.
+func (r TableRow) All() iter.Seq2[FieldDef, any] {
+	return func(yield func(FieldDef, any) bool) {
+		for _, f := range r.Schema {
+			if !yield(f, r.Values[f.Name]) {
+				return
+			}
+		}
+	}
+}
+
+// LoadTable loads the data rows of the named table from the rulebook at
+// path, without needing a generated struct
+func LoadTable(path, tableName string) ([]TableRow, error) {
+	rb, err := LoadFromRulebook(path)
+	if err != nil {
+		return nil, err
+	}
+	table, ok := rb.Tables[tableName]
+	if !ok {
+		return nil, fmt.Errorf("%s: rulebook has no table %q (tables: %s)", path, tableName, strings.Join(rb.TableNames, ", "))
+	}
+
+	values, err := RecordMaps(table)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", path, tableName, err)
+	}
+	rows := make([]TableRow, len(values))
+	for i := range values {
+		rows[i] = TableRow{Schema: table.Schema, Values: values[i]}
+	}
+	return rows, nil
+}
+
+// GenerateBlankTest returns the rulebook's LanguageCandidates with every
+// calculated field cleared (the questions without the answers), in
+// language_candidate_id order like testing/blank-tests
+func GenerateBlankTest(rb *Rulebook) []LanguageCandidate {
+	blank := make([]LanguageCandidate, len(rb.LanguageCandidates))
+	copy(blank, rb.LanguageCandidates)
+	for i := range blank {
+		clearFields(&blank[i], LanguageCandidateCalculatedFields)
+	}
+	sort.SliceStable(blank, func(i, j int) bool {
+		return blank[i].LanguageCandidateId < blank[j].LanguageCandidateId
+	})
+	return blank
+}
+
+// ExtractFixture returns the candidates with the given ids, in ids order,
+// with every calculated field cleared: a blank test built from real
+// (possibly computed) records. Raw fields are kept as they are. It is an
+// error for an id to be missing from candidates.
+func ExtractFixture(candidates []LanguageCandidate, ids []string) ([]LanguageCandidate, error) {
+	fixture := make([]LanguageCandidate, 0, len(ids))
+	var missing []string
+	for _, id := range ids {
+		i := slices.IndexFunc(candidates, func(lc LanguageCandidate) bool { return lc.LanguageCandidateId == id })
+		if i < 0 {
+			missing = append(missing, id)
+			continue
+		}
+		blank := candidates[i]
+		clearFields(&blank, LanguageCandidateCalculatedFields)
+		fixture = append(fixture, blank)
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("no candidate with language_candidate_id %s", strings.Join(missing, ", "))
+	}
+	return fixture, nil
+}
+
+// AllViews computes every LanguageCandidate's view (ComputeView) in
+// SortOrder order. Candidates with no SortOrder come last, and ties are
+// broken by language_candidate_id.
+func (rb *Rulebook) AllViews() []LanguageCandidate {
+	views := slices.Collect(Views(rb.LanguageCandidates))
+	sort.SliceStable(views, func(i, j int) bool {
+		a, b := views[i].SortOrder, views[j].SortOrder
+		switch {
+		case a == nil && b != nil:
+			return false
+		case a != nil && b == nil:
+			return true
+		case a != nil && *a != *b:
+			return *a < *b
+		}
+		return views[i].LanguageCandidateId < views[j].LanguageCandidateId
+	})
+	return views
+}
+
+// AssertBlankTest checks that the blank test at path is really blank: every
+// calculated field (question, predicted_answer, prediction_fail,
+// has_grammar, relationship_to_concept, ...) is null or absent on every
+// candidate, so the substrate has to compute them. The error lists each
+// populated field by candidate.
+func AssertBlankTest(path string) error {
+	rows, err := LoadRecords[map[string]json.RawMessage](path)
+	if err != nil {
+		return err
+	}
+
+	var populated []string
+	for i, row := range rows {
+		var fields []string
+		for _, name := range LanguageCandidateCalculatedFields {
+			if raw, ok := row[name]; ok && string(bytes.TrimSpace(raw)) != "null" {
+				fields = append(fields, name)
+			}
+		}
+		if len(fields) > 0 {
+			id := fmt.Sprintf("row %d", i)
+			json.Unmarshal(row["language_candidate_id"], &id)
+			populated = append(populated, fmt.Sprintf("%s (%s)", id, strings.Join(fields, ", ")))
+		}
+	}
+	if len(populated) > 0 {
+		return fmt.Errorf("%s is not blank: %d candidates have calculated fields populated: %s", path, len(populated), strings.Join(populated, "; "))
+	}
+	return nil
+}
+
+// VerifyGeneratedChecksum checks that erb_sdk.go was generated from the
+// rulebook at rulebookPath by the generator whose source files are
+// generatorSources (normally GeneratorSources), by comparing their sha256
+// with the checksums inject-into-golang.py embedded
+func VerifyGeneratedChecksum(rulebookPath string, generatorSources []string) error {
+	data, err := os.ReadFile(rulebookPath)
+	if err != nil {
+		return fmt.Errorf("failed to read rulebook: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); got != GeneratedRulebookChecksum {
+		return fmt.Errorf("erb_sdk.go is stale: generated from rulebook sha256 %s, but %s is %s (re-run inject-into-golang.py)",
+			GeneratedRulebookChecksum, rulebookPath, got)
+	}
+
+	h := sha256.New()
+	for _, path := range generatorSources {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read generator source: %w", err)
+		}
+		h.Write(data)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != GeneratedGeneratorChecksum {
+		return fmt.Errorf("erb_sdk.go is stale: generated by generator sha256 %s, but %s hash to %s (re-run inject-into-golang.py)",
+			GeneratedGeneratorChecksum, strings.Join(generatorSources, ", "), got)
+	}
+	return nil
+}
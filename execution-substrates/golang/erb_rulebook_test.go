@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVerifyGeneratedChecksum(t *testing.T) {
+	if err := VerifyGeneratedChecksum(DefaultRulebookPath, GeneratorSources); err != nil {
+		t.Fatalf("checked-in erb_sdk.go: %v", err)
+	}
+
+	rulebook := writeFile(t, "rulebook.json", append(readFile(t, DefaultRulebookPath), '\n'))
+	if err := VerifyGeneratedChecksum(rulebook, GeneratorSources); err == nil || !strings.Contains(err.Error(), "rulebook sha256") {
+		t.Errorf("edited rulebook: got %v, want a stale rulebook error", err)
+	}
+
+	generator := slices.Clone(GeneratorSources)
+	generator[0] = writeFile(t, "inject-into-golang.py", append(readFile(t, GeneratorSources[0]), "# edited\n"...))
+	if err := VerifyGeneratedChecksum(DefaultRulebookPath, generator); err == nil || !strings.Contains(err.Error(), "generator sha256") {
+		t.Errorf("edited generator: got %v, want a stale generator error", err)
+	}
+}
+
+func TestLoadFromRulebookURL(t *testing.T) {
+	rulebook := readFile(t, DefaultRulebookPath)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rulebook.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(rulebook)
+	}))
+	defer srv.Close()
+
+	rb, err := LoadFromRulebookURL(context.Background(), srv.URL+"/rulebook.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := loadTestRulebook(t); len(rb.LanguageCandidates) != len(want.LanguageCandidates) {
+		t.Errorf("fetched %d candidates, want %d", len(rb.LanguageCandidates), len(want.LanguageCandidates))
+	}
+
+	if _, err := LoadFromRulebookURL(context.Background(), srv.URL+"/missing.json"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("non-200 response: got %v, want a 404 error", err)
+	}
+
+	saved := RulebookMaxBytes
+	t.Cleanup(func() { RulebookMaxBytes = saved })
+	RulebookMaxBytes = int64(len(rulebook)) - 1
+	var limitErr *LimitError
+	if _, err := LoadFromRulebookURL(context.Background(), srv.URL+"/rulebook.json"); !errors.As(err, &limitErr) || limitErr.Limit != "bytes" {
+		t.Errorf("oversize body: got %v, want a bytes *LimitError", err)
+	}
+	RulebookMaxBytes = int64(len(rulebook))
+	if _, err := LoadFromRulebookURL(context.Background(), srv.URL+"/rulebook.json"); err != nil {
+		t.Errorf("body of exactly RulebookMaxBytes: %v", err)
+	}
+}
+
+func TestLoadTable(t *testing.T) {
+	rows, err := LoadTable(DefaultRulebookPath, "LanguageCandidates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rb := loadTestRulebook(t)
+	if len(rows) != len(rb.LanguageCandidates) {
+		t.Fatalf("LoadTable returned %d rows, want %d", len(rows), len(rb.LanguageCandidates))
+	}
+
+	python := slices.IndexFunc(rows, func(r TableRow) bool { return r.Values["LanguageCandidateId"] == "python" })
+	if python < 0 {
+		t.Fatal("no python row")
+	}
+	var names []string
+	kinds := map[string]string{}
+	for f, v := range rows[python].All() {
+		names = append(names, f.Name)
+		kinds[f.Name] = f.Type
+		if f.Name == "DistanceFromConcept" && v != 2 {
+			t.Errorf("DistanceFromConcept = %v (%T), want the integer 2", v, v)
+		}
+	}
+	if names[0] != "LanguageCandidateId" || names[1] != "Name" {
+		t.Errorf("fields start %v, want schema order", names[:2])
+	}
+	if kinds["HasSyntax"] != "raw" || kinds["PredictedAnswer"] != "calculated" {
+		t.Errorf("HasSyntax is %q and PredictedAnswer %q, want raw and calculated", kinds["HasSyntax"], kinds["PredictedAnswer"])
+	}
+
+	if _, err := LoadTable(DefaultRulebookPath, "Customers"); err == nil || !strings.Contains(err.Error(), `no table "Customers"`) {
+		t.Errorf("LoadTable of a missing table = %v, want an error naming it", err)
+	}
+}
+
+func TestLoadFromRulebookStrictRejectsUnknownKeys(t *testing.T) {
+	if _, err := LoadFromRulebookStrict(DefaultRulebookPath); err != nil {
+		t.Fatalf("LoadFromRulebookStrict on the rulebook: %v", err)
+	}
+
+	renamed := writeFile(t, "renamed.json", []byte(`{
+  "LanguageCandidates": {
+    "schema": [{"name": "LanguageCandidateId", "type": "raw"}, {"name": "HasSyntx", "type": "raw"}],
+    "data": [{"LanguageCandidateId": "python", "HasSyntx": true}]
+  }
+}`))
+	if _, err := LoadFromRulebook(renamed); err != nil {
+		t.Fatalf("LoadFromRulebook = %v, want the unknown key ignored", err)
+	}
+	_, err := LoadFromRulebookStrict(renamed)
+	if err == nil || !strings.Contains(err.Error(), "HasSyntx") || !strings.Contains(err.Error(), "LanguageCandidates") {
+		t.Errorf("LoadFromRulebookStrict = %v, want an error naming HasSyntx and LanguageCandidates", err)
+	}
+}
+
+func TestAssertBlankTest(t *testing.T) {
+	if err := AssertBlankTest(filepath.Join(defaultBlankTestsDir, "language_candidates.json")); err != nil {
+		t.Errorf("AssertBlankTest on the shipped blank test: %v", err)
+	}
+
+	err := AssertBlankTest("testdata/blank/not-blank.json")
+	if err == nil {
+		t.Fatal("AssertBlankTest on a filled-in fixture = nil, want an error")
+	}
+	if want := "a-coffee-mug (has_grammar, relationship_to_concept)"; !strings.Contains(err.Error(), want) || strings.Contains(err.Error(), "python") {
+		t.Errorf("AssertBlankTest = %v, want only %q reported", err, want)
+	}
+}
+
+func TestAllViewsSortOrder(t *testing.T) {
+	rb := &Rulebook{LanguageCandidates: []LanguageCandidate{
+		{LanguageCandidateId: "unsorted-b"},
+		{LanguageCandidateId: "second", SortOrder: ptr(2)},
+		{LanguageCandidateId: "unsorted-a"},
+		{LanguageCandidateId: "first-b", SortOrder: ptr(1)},
+		{LanguageCandidateId: "first-a", SortOrder: ptr(1)},
+	}}
+	var ids []string
+	for _, view := range rb.AllViews() {
+		if view.Question == nil {
+			t.Errorf("%s was not computed", view.LanguageCandidateId)
+		}
+		ids = append(ids, view.LanguageCandidateId)
+	}
+	if want := []string{"first-a", "first-b", "second", "unsorted-a", "unsorted-b"}; !slices.Equal(ids, want) {
+		t.Errorf("AllViews order = %v, want %v", ids, want)
+	}
+}
+
+func TestExtractFixture(t *testing.T) {
+	computed := computedCandidates(t)
+	fixture, err := ExtractFixture(computed, []string{"python", "a-coffee-mug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixture) != 2 || fixture[0].LanguageCandidateId != "python" || fixture[1].LanguageCandidateId != "a-coffee-mug" {
+		t.Fatalf("ExtractFixture = %d records, want python and a-coffee-mug in ids order", len(fixture))
+	}
+
+	for _, lc := range fixture {
+		source := candidateByID(t, computed, lc.LanguageCandidateId)
+		for _, f := range CompareCandidates(source, lc) {
+			calculated := slices.Contains(LanguageCandidateCalculatedFields, f.Field)
+			switch {
+			case calculated && f.B != nil:
+				t.Errorf("%s.%s = %v, want nil", lc.LanguageCandidateId, f.Field, f.B)
+			case !calculated && f.Differs:
+				t.Errorf("%s.%s = %v, want the source's %v", lc.LanguageCandidateId, f.Field, f.B, f.A)
+			}
+		}
+	}
+	if source := candidateByID(t, computed, "python"); source.PredictedAnswer == nil {
+		t.Error("ExtractFixture cleared the source candidate")
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := SaveLanguageCandidateRecords(path, fixture); err != nil {
+		t.Fatal(err)
+	}
+	if err := AssertBlankTest(path); err != nil {
+		t.Errorf("saved fixture is not blank: %v", err)
+	}
+
+	if _, err := ExtractFixture(computed, []string{"python", "nope"}); err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("ExtractFixture with a missing id = %v, want an error naming it", err)
+	}
+}
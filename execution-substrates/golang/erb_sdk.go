@@ -1,6 +1,8 @@
 // ERB SDK - Go Implementation (GENERATED - DO NOT EDIT)
 // ======================================================
 // Generated from: effortless-rulebook/effortless-rulebook.json
+// Rulebook sha256: b6cf2821a5782566c810cc730a31bfe048821192e0b14771f9e43b484eaa95ab
+// Generator sha256: f917a13f1f47f1b8a5451114679ddd8c830abf9187bc941ceddae0a44fbd9fd1
 //
 // This file contains structs and calculation functions
 // for all tables defined in the rulebook.
@@ -11,9 +13,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 )
 
+// GeneratedRulebookChecksum is the sha256 of the rulebook this file was generated from
+const GeneratedRulebookChecksum = "b6cf2821a5782566c810cc730a31bfe048821192e0b14771f9e43b484eaa95ab"
+
+// GeneratedGeneratorChecksum is the sha256 of the GeneratorSources, concatenated, that generated this file
+const GeneratedGeneratorChecksum = "f917a13f1f47f1b8a5451114679ddd8c830abf9187bc941ceddae0a44fbd9fd1"
+
+// GeneratorSources are the generator's own source files, relative to this substrate directory
+var GeneratorSources = []string{"inject-into-golang.py", filepath.Join("..", "..", "orchestration", "formula_parser.py")}
+
 // =============================================================================
 // HELPER FUNCTIONS
 // =============================================================================
@@ -58,6 +70,101 @@ func boolToString(b bool) string {
 	return "false"
 }
 
+// OutputDenylist holds filepath.Match patterns (matched against the base name) that save functions refuse to write
+var OutputDenylist = []string{"*.go"}
+
+// ForceOverwrite lets save functions write to denylisted paths (the -force flag)
+var ForceOverwrite = false
+
+// checkOutputPath refuses denylisted output paths unless ForceOverwrite is set
+func checkOutputPath(path string) error {
+	if ForceOverwrite {
+		return nil
+	}
+	for _, pattern := range OutputDenylist {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return fmt.Errorf("refusing to overwrite %s: matches protected pattern %q (use -force)", path, pattern)
+		}
+	}
+	return nil
+}
+
+// Record is implemented by every table struct so mixed batches can be processed uniformly
+type Record interface {
+	TableName() string
+	Compute() Record
+}
+
+// ComputeGraph evaluates a record's calculated fields in dependency order.
+// The order is sorted at runtime from each field's declared dependencies,
+// so a new calculated field never requires reordering code by hand.
+type ComputeGraph[T any] struct {
+	Order []string // calculated field json names in evaluation order
+	steps map[string]func(record *T)
+}
+
+// NewComputeGraph topologically sorts fields by deps (ties keep the order
+// of fields) and returns an error naming the fields caught in a cycle.
+// Each step computes one field and stores it on the record.
+func NewComputeGraph[T any](fields []string, deps map[string][]string, steps map[string]func(record *T)) (*ComputeGraph[T], error) {
+	pending := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if steps[f] == nil {
+			return nil, fmt.Errorf("calculated field %s has no compute step", f)
+		}
+		pending[f] = true
+	}
+
+	g := &ComputeGraph[T]{steps: steps}
+	for len(g.Order) < len(fields) {
+		progressed := false
+		for _, f := range fields {
+			if !pending[f] {
+				continue
+			}
+			ready := true
+			for _, d := range deps[f] {
+				if pending[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				g.Order = append(g.Order, f)
+				pending[f] = false
+				progressed = true
+			}
+		}
+		if !progressed {
+			var cycle []string
+			for _, f := range fields {
+				if pending[f] {
+					cycle = append(cycle, f)
+				}
+			}
+			return nil, fmt.Errorf("calculated fields form a dependency cycle: %v", cycle)
+		}
+	}
+	return g, nil
+}
+
+// mustComputeGraph is NewComputeGraph for the package-level graphs: a cycle
+// in the rulebook fails loudly at startup
+func mustComputeGraph[T any](fields []string, deps map[string][]string, steps map[string]func(record *T)) *ComputeGraph[T] {
+	g, err := NewComputeGraph(fields, deps, steps)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Evaluate computes every calculated field of record in Order
+func (g *ComputeGraph[T]) Evaluate(record *T) {
+	for _, f := range g.Order {
+		g.steps[f](record)
+	}
+}
+
 // =============================================================================
 // LANGUAGECANDIDATES TABLE
 // =============================================================================
@@ -108,6 +215,56 @@ type LanguageCandidate struct {
 	RelationshipToConcept *string `json:"relationship_to_concept"`
 }
 
+// LanguageCandidateRawFields lists the raw fields of the LanguageCandidates table (json names, schema order)
+var LanguageCandidateRawFields = []string{
+	"language_candidate_id",
+	"name",
+	"is_language",
+	"has_syntax",
+	"can_be_held",
+	"category",
+	"has_identity",
+	"is_parsed",
+	"resolves_to_an_ast",
+	"has_linear_decoding_pressure",
+	"is_stable_ontology_reference",
+	"is_live_ontology_editor",
+	"is_open_world",
+	"is_closed_world",
+	"distance_from_concept",
+	"dimensionality_while_editing",
+	"model_object_facility_layer",
+	"sort_order",
+	"bio_has_semanticity",
+	"bio_has_arbitrariness",
+	"bio_has_discreteness",
+	"bio_has_duality_of_patterning",
+	"bio_has_productivity",
+	"bio_has_displacement",
+	"bio_has_cultural_transmission",
+	"bio_has_interchangeability",
+	"bio_has_feedback",
+	"bio_has_broadcast_transmission",
+	"bio_has_rapid_fading",
+	"bio_is_evolved_communication_system",
+	"bio_primary_modality",
+}
+
+// LanguageCandidateCalculatedFields lists the calculated fields of the LanguageCandidates table (json names, schema order)
+var LanguageCandidateCalculatedFields = []string{
+	"has_grammar",
+	"question",
+	"predicted_answer",
+	"predicted_biological_language_core",
+	"predicted_biological_language_strict",
+	"bio_hockett_score",
+	"prediction_predicates",
+	"prediction_fail",
+	"is_description_of",
+	"is_open_closed_world_conflicted",
+	"relationship_to_concept",
+}
+
 // --- Individual Calculation Functions ---
 
 // CalcHasGrammar computes the HasGrammar calculated field
@@ -176,71 +333,68 @@ func (tc *LanguageCandidate) CalcRelationshipToConcept() string {
 	return func() string { if (tc.DistanceFromConcept != nil && *tc.DistanceFromConcept == 1) { return "IsMirrorOf" }; return "IsDescriptionOf" }()
 }
 
+// LanguageCandidateFieldDeps maps each calculated field to the fields its formula references (json names)
+var LanguageCandidateFieldDeps = map[string][]string{
+	"has_grammar": {"has_syntax"},
+	"question": {"name"},
+	"predicted_answer": {"has_syntax", "is_parsed", "is_description_of", "has_linear_decoding_pressure", "resolves_to_an_ast", "is_stable_ontology_reference", "can_be_held", "has_identity", "bio_hockett_score"},
+	"predicted_biological_language_core": {"bio_is_evolved_communication_system", "bio_has_semanticity", "bio_has_arbitrariness", "bio_has_discreteness", "bio_has_duality_of_patterning", "bio_has_productivity", "bio_has_displacement", "bio_has_cultural_transmission"},
+	"predicted_biological_language_strict": {"predicted_biological_language_core", "bio_has_interchangeability", "bio_has_feedback"},
+	"bio_hockett_score": {"bio_has_semanticity", "bio_has_arbitrariness", "bio_has_discreteness", "bio_has_duality_of_patterning", "bio_has_productivity", "bio_has_displacement", "bio_has_cultural_transmission", "bio_has_interchangeability", "bio_has_feedback", "bio_has_broadcast_transmission", "bio_has_rapid_fading"},
+	"prediction_predicates": {"has_syntax", "is_parsed", "is_description_of", "has_linear_decoding_pressure", "resolves_to_an_ast", "is_stable_ontology_reference", "can_be_held", "has_identity"},
+	"prediction_fail": {"predicted_answer", "is_language", "name", "is_open_closed_world_conflicted"},
+	"is_description_of": {"distance_from_concept"},
+	"is_open_closed_world_conflicted": {"is_open_world", "is_closed_world"},
+	"relationship_to_concept": {"distance_from_concept"},
+}
+
+// LanguageCandidateCalcFuncs maps calculated field json names to their Calc* methods
+var LanguageCandidateCalcFuncs = map[string]func(tc *LanguageCandidate) any{
+	"has_grammar": func(tc *LanguageCandidate) any { return tc.CalcHasGrammar() },
+	"question": func(tc *LanguageCandidate) any { return tc.CalcQuestion() },
+	"predicted_answer": func(tc *LanguageCandidate) any { return tc.CalcPredictedAnswer() },
+	"predicted_biological_language_core": func(tc *LanguageCandidate) any { return tc.CalcPredictedBiologicalLanguage_Core() },
+	"predicted_biological_language_strict": func(tc *LanguageCandidate) any { return tc.CalcPredictedBiologicalLanguage_Strict() },
+	"bio_hockett_score": func(tc *LanguageCandidate) any { return tc.CalcBio_HockettScore() },
+	"prediction_predicates": func(tc *LanguageCandidate) any { return tc.CalcPredictionPredicates() },
+	"prediction_fail": func(tc *LanguageCandidate) any { return tc.CalcPredictionFail() },
+	"is_description_of": func(tc *LanguageCandidate) any { return tc.CalcIsDescriptionOf() },
+	"is_open_closed_world_conflicted": func(tc *LanguageCandidate) any { return tc.CalcIsOpenClosedWorldConflicted() },
+	"relationship_to_concept": func(tc *LanguageCandidate) any { return tc.CalcRelationshipToConcept() },
+}
+
 // --- Compute All Calculated Fields ---
 
+// LanguageCandidateComputeGraph evaluates the calculated fields of a LanguageCandidate in dependency order
+var LanguageCandidateComputeGraph = mustComputeGraph(LanguageCandidateCalculatedFields, LanguageCandidateFieldDeps, map[string]func(tc *LanguageCandidate){
+	"has_grammar": func(tc *LanguageCandidate) { v := tc.CalcHasGrammar(); tc.HasGrammar = &v },
+	"question": func(tc *LanguageCandidate) { tc.Question = nilIfEmpty(tc.CalcQuestion()) },
+	"predicted_answer": func(tc *LanguageCandidate) { v := tc.CalcPredictedAnswer(); tc.PredictedAnswer = &v },
+	"predicted_biological_language_core": func(tc *LanguageCandidate) { v := tc.CalcPredictedBiologicalLanguage_Core(); tc.PredictedBiologicalLanguage_Core = &v },
+	"predicted_biological_language_strict": func(tc *LanguageCandidate) { v := tc.CalcPredictedBiologicalLanguage_Strict(); tc.PredictedBiologicalLanguage_Strict = &v },
+	"bio_hockett_score": func(tc *LanguageCandidate) { v := tc.CalcBio_HockettScore(); tc.Bio_HockettScore = &v },
+	"prediction_predicates": func(tc *LanguageCandidate) { tc.PredictionPredicates = nilIfEmpty(tc.CalcPredictionPredicates()) },
+	"prediction_fail": func(tc *LanguageCandidate) { tc.PredictionFail = nilIfEmpty(tc.CalcPredictionFail()) },
+	"is_description_of": func(tc *LanguageCandidate) { v := tc.CalcIsDescriptionOf(); tc.IsDescriptionOf = &v },
+	"is_open_closed_world_conflicted": func(tc *LanguageCandidate) { v := tc.CalcIsOpenClosedWorldConflicted(); tc.IsOpenClosedWorldConflicted = &v },
+	"relationship_to_concept": func(tc *LanguageCandidate) { tc.RelationshipToConcept = nilIfEmpty(tc.CalcRelationshipToConcept()) },
+})
+
 // ComputeAll computes all calculated fields and returns an updated struct
 func (tc *LanguageCandidate) ComputeAll() *LanguageCandidate {
-	// Level 1 calculations
-	hasGrammar := (boolVal(tc.HasSyntax) == true)
-	question := "Is " + stringVal(tc.Name) + " a language?"
-	predictedBiologicalLanguage_Core := (boolVal(tc.Bio_IsEvolvedCommunicationSystem) && boolVal(tc.Bio_HasSemanticity) && boolVal(tc.Bio_HasArbitrariness) && boolVal(tc.Bio_HasDiscreteness) && boolVal(tc.Bio_HasDualityOfPatterning) && boolVal(tc.Bio_HasProductivity) && boolVal(tc.Bio_HasDisplacement) && boolVal(tc.Bio_HasCulturalTransmission))
-	bio_HockettScore := (func() int { if boolVal(tc.Bio_HasSemanticity) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasArbitrariness) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasDiscreteness) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasDualityOfPatterning) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasProductivity) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasDisplacement) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasCulturalTransmission) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasInterchangeability) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasFeedback) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasBroadcastTransmission) { return 1 }; return 0 }() + func() int { if boolVal(tc.Bio_HasRapidFading) { return 1 }; return 0 }())
-	isDescriptionOf := (tc.DistanceFromConcept != nil && *tc.DistanceFromConcept > 1)
-	isOpenClosedWorldConflicted := (boolVal(tc.IsOpenWorld) && boolVal(tc.IsClosedWorld))
-	relationshipToConcept := func() string { if (tc.DistanceFromConcept != nil && *tc.DistanceFromConcept == 1) { return "IsMirrorOf" }; return "IsDescriptionOf" }()
-
-	// Level 2 calculations
-	predictedAnswer := ((boolVal(tc.HasSyntax) && boolVal(tc.IsParsed) && isDescriptionOf && boolVal(tc.HasLinearDecodingPressure) && boolVal(tc.ResolvesToAnAST) && boolVal(tc.IsStableOntologyReference) && !boolVal(tc.CanBeHeld) && !boolVal(tc.HasIdentity)) || (bio_HockettScore > 0))
-	predictedBiologicalLanguage_Strict := (predictedBiologicalLanguage_Core && boolVal(tc.Bio_HasInterchangeability) && boolVal(tc.Bio_HasFeedback))
-	predictionPredicates := func() string { if boolVal(tc.HasSyntax) { return "Has Syntax" }; return "No Syntax" }() + " & " + func() string { if boolVal(tc.IsParsed) { return "Requires Parsing" }; return "No Parsing Neede" }() + " & " + func() string { if isDescriptionOf { return "Describes the thing" }; return "Is the Thing" }() + " & " + func() string { if boolVal(tc.HasLinearDecodingPressure) { return "Has Linear Decoding Pressure" }; return "No Decoding Pressure" }() + " & " + func() string { if boolVal(tc.ResolvesToAnAST) { return "Resolves to AST" }; return "No AST" }() + ", " + func() string { if boolVal(tc.IsStableOntologyReference) { return "Is Stable Ontology" }; return "Not 'Ontology'" }() + " AND " + func() string { if boolVal(tc.CanBeHeld) { return "Can Be Held" }; return "Can't Be Held" }() + ", " + func() string { if boolVal(tc.HasIdentity) { return "Has Identity" }; return "Has no Identity" }()
-
-	// Level 3 calculations
-	predictionFail := func() string { if !((predictedAnswer == boolVal(tc.IsLanguage))) { return stringVal(tc.Name) + " " + func() string { if predictedAnswer { return "Is" }; return "Isn't" }() + " a Family Feud Language, but " + func() string { if boolVal(tc.IsLanguage) { return "Is" }; return "Is Not" }() + " marked as a 'Language Candidate.'" }; return "" }() + func() string { if isOpenClosedWorldConflicted { return " - Open World vs. Closed World Conflict." }; return "" }()
-
-	return &LanguageCandidate{
-		LanguageCandidateId: tc.LanguageCandidateId,
-		Name: tc.Name,
-		IsLanguage: tc.IsLanguage,
-		HasSyntax: tc.HasSyntax,
-		CanBeHeld: tc.CanBeHeld,
-		Category: tc.Category,
-		HasIdentity: tc.HasIdentity,
-		IsParsed: tc.IsParsed,
-		ResolvesToAnAST: tc.ResolvesToAnAST,
-		HasLinearDecodingPressure: tc.HasLinearDecodingPressure,
-		IsStableOntologyReference: tc.IsStableOntologyReference,
-		IsLiveOntologyEditor: tc.IsLiveOntologyEditor,
-		IsOpenWorld: tc.IsOpenWorld,
-		IsClosedWorld: tc.IsClosedWorld,
-		DistanceFromConcept: tc.DistanceFromConcept,
-		DimensionalityWhileEditing: tc.DimensionalityWhileEditing,
-		ModelObjectFacilityLayer: tc.ModelObjectFacilityLayer,
-		SortOrder: tc.SortOrder,
-		Bio_HasSemanticity: tc.Bio_HasSemanticity,
-		Bio_HasArbitrariness: tc.Bio_HasArbitrariness,
-		Bio_HasDiscreteness: tc.Bio_HasDiscreteness,
-		Bio_HasDualityOfPatterning: tc.Bio_HasDualityOfPatterning,
-		Bio_HasProductivity: tc.Bio_HasProductivity,
-		Bio_HasDisplacement: tc.Bio_HasDisplacement,
-		Bio_HasCulturalTransmission: tc.Bio_HasCulturalTransmission,
-		Bio_HasInterchangeability: tc.Bio_HasInterchangeability,
-		Bio_HasFeedback: tc.Bio_HasFeedback,
-		Bio_HasBroadcastTransmission: tc.Bio_HasBroadcastTransmission,
-		Bio_HasRapidFading: tc.Bio_HasRapidFading,
-		Bio_IsEvolvedCommunicationSystem: tc.Bio_IsEvolvedCommunicationSystem,
-		Bio_PrimaryModality: tc.Bio_PrimaryModality,
-		HasGrammar: &hasGrammar,
-		Question: nilIfEmpty(question),
-		PredictedAnswer: &predictedAnswer,
-		PredictedBiologicalLanguage_Core: &predictedBiologicalLanguage_Core,
-		PredictedBiologicalLanguage_Strict: &predictedBiologicalLanguage_Strict,
-		Bio_HockettScore: &bio_HockettScore,
-		PredictionPredicates: nilIfEmpty(predictionPredicates),
-		PredictionFail: nilIfEmpty(predictionFail),
-		IsDescriptionOf: &isDescriptionOf,
-		IsOpenClosedWorldConflicted: &isOpenClosedWorldConflicted,
-		RelationshipToConcept: nilIfEmpty(relationshipToConcept),
-	}
+	result := *tc
+	LanguageCandidateComputeGraph.Evaluate(&result)
+	return &result
+}
+
+// TableName returns the rulebook table name for LanguageCandidate records
+func (tc *LanguageCandidate) TableName() string {
+	return "LanguageCandidates"
+}
+
+// Compute returns the record with all calculated fields computed
+func (tc *LanguageCandidate) Compute() Record {
+	return tc.ComputeAll()
 }
 
 // =============================================================================
@@ -262,6 +416,34 @@ type IsEverythingALanguage struct {
 	Notes *string `json:"notes"`
 }
 
+// IsEverythingALanguageRawFields lists the raw fields of the IsEverythingALanguage table (json names, schema order)
+var IsEverythingALanguageRawFields = []string{
+	"is_everything_a_language_id",
+	"name",
+	"argument_name",
+	"argument_category",
+	"step_type",
+	"statement",
+	"formalization",
+	"related_candidate_name",
+	"related_candidate_id",
+	"evidence_from_rulebook",
+	"notes",
+}
+
+// IsEverythingALanguageCalculatedFields lists the calculated fields of the IsEverythingALanguage table (json names, schema order)
+var IsEverythingALanguageCalculatedFields = []string{}
+
+// TableName returns the rulebook table name for IsEverythingALanguage records
+func (tc *IsEverythingALanguage) TableName() string {
+	return "IsEverythingALanguage"
+}
+
+// Compute returns the record unchanged (the table has no calculated fields)
+func (tc *IsEverythingALanguage) Compute() Record {
+	return tc
+}
+
 // =============================================================================
 // ERBCUSTOMIZATIONS TABLE
 // =============================================================================
@@ -276,18 +458,41 @@ type ERBCustomization struct {
 	CustomizationType *string `json:"customization_type"`
 }
 
+// ERBCustomizationRawFields lists the raw fields of the ERBCustomizations table (json names, schema order)
+var ERBCustomizationRawFields = []string{
+	"erb_customization_id",
+	"name",
+	"title",
+	"sql_code",
+	"sql_target",
+	"customization_type",
+}
+
+// ERBCustomizationCalculatedFields lists the calculated fields of the ERBCustomizations table (json names, schema order)
+var ERBCustomizationCalculatedFields = []string{}
+
+// TableName returns the rulebook table name for ERBCustomization records
+func (tc *ERBCustomization) TableName() string {
+	return "ERBCustomizations"
+}
+
+// Compute returns the record unchanged (the table has no calculated fields)
+func (tc *ERBCustomization) Compute() Record {
+	return tc
+}
+
 // =============================================================================
 // FILE I/O FUNCTIONS (for all tables with calculated fields)
 // =============================================================================
 
-// LoadLanguageCandidateRecords loads LanguageCandidates records from a JSON file
-func LoadLanguageCandidateRecords(path string) ([]LanguageCandidate, error) {
+// LoadRecords loads a JSON array of records of any table from a file
+func LoadRecords[T any](path string) ([]T, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	var records []LanguageCandidate
+	var records []T
 	if err := json.Unmarshal(data, &records); err != nil {
 		return nil, fmt.Errorf("failed to parse file: %w", err)
 	}
@@ -295,8 +500,12 @@ func LoadLanguageCandidateRecords(path string) ([]LanguageCandidate, error) {
 	return records, nil
 }
 
-// SaveLanguageCandidateRecords saves computed LanguageCandidates records to a JSON file
-func SaveLanguageCandidateRecords(path string, records []LanguageCandidate) error {
+// SaveRecords saves records of any table to a JSON file as a 2-space indented array
+func SaveRecords[T any](path string, records []T) error {
+	if err := checkOutputPath(path); err != nil {
+		return err
+	}
+
 	data, err := json.MarshalIndent(records, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal records: %w", err)
@@ -308,3 +517,13 @@ func SaveLanguageCandidateRecords(path string, records []LanguageCandidate) erro
 
 	return nil
 }
+
+// LoadLanguageCandidateRecords loads LanguageCandidates records from a JSON file
+func LoadLanguageCandidateRecords(path string) ([]LanguageCandidate, error) {
+	return LoadRecords[LanguageCandidate](path)
+}
+
+// SaveLanguageCandidateRecords saves computed LanguageCandidates records to a JSON file
+func SaveLanguageCandidateRecords(path string, records []LanguageCandidate) error {
+	return SaveRecords(path, records)
+}
@@ -0,0 +1,281 @@
+// ERB SDK - Serializers (hand-written)
+// ====================================
+// One interface for every output format, so a computed slice can be saved
+// as JSON, CSV, YAML or protobuf through the same SaveWith call.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Serializer renders computed LanguageCandidates in one output format
+type Serializer interface {
+	Serialize(views []LanguageCandidate) ([]byte, error)
+	Extension() string
+}
+
+// BoolFormat is how exports render boolean fields. BoolNative means real
+// JSON booleans in JSON output and the text "true"/"false" in CSV.
+type BoolFormat int
+
+const (
+	BoolNative  BoolFormat = iota // true / false
+	BoolNumeric                   // 1 / 0
+	BoolYesNo                     // "yes" / "no"
+)
+
+var boolFormatNames = []string{"native", "numeric", "yesno"}
+
+// ParseBoolFormat parses "native", "numeric" or "yesno"
+func ParseBoolFormat(s string) (BoolFormat, error) {
+	for i, name := range boolFormatNames {
+		if s == name {
+			return BoolFormat(i), nil
+		}
+	}
+	return BoolNative, fmt.Errorf("unknown bool format %q (want native, numeric or yesno)", s)
+}
+
+func (f BoolFormat) MarshalText() ([]byte, error) {
+	if f < 0 || int(f) >= len(boolFormatNames) {
+		return nil, fmt.Errorf("invalid bool format %d", int(f))
+	}
+	return []byte(boolFormatNames[f]), nil
+}
+
+// text renders b as a CSV cell
+func (f BoolFormat) text(b bool) string {
+	switch {
+	case f == BoolNumeric && b:
+		return "1"
+	case f == BoolNumeric:
+		return "0"
+	case f == BoolYesNo && b:
+		return "yes"
+	case f == BoolYesNo:
+		return "no"
+	}
+	return strconv.FormatBool(b)
+}
+
+// applyJSON rewrites the true/false literals of a JSON document (outside
+// strings) in format f, keeping its layout
+func (f BoolFormat) applyJSON(data []byte) []byte {
+	if f == BoolNative {
+		return data
+	}
+	var out bytes.Buffer
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			if c == '\\' && i+1 < len(data) {
+				out.WriteByte(c)
+				i++
+				c = data[i]
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case bytes.HasPrefix(data[i:], []byte("true")) || bytes.HasPrefix(data[i:], []byte("false")):
+			b := c == 't'
+			if f == BoolNumeric {
+				out.WriteString(f.text(b))
+			} else {
+				out.WriteString(strconv.Quote(f.text(b)))
+			}
+			i += len(strconv.FormatBool(b)) - 1
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.Bytes()
+}
+
+// JSONSerializer writes the same 2-space indented array as SaveLanguageCandidateRecords
+type JSONSerializer struct {
+	Bools BoolFormat
+}
+
+func (JSONSerializer) Extension() string { return ".json" }
+
+func (s JSONSerializer) Serialize(views []LanguageCandidate) ([]byte, error) {
+	data, err := json.MarshalIndent(views, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return s.Bools.applyJSON(data), nil
+}
+
+// CSVSerializer writes a header row of json field names and one row per candidate.
+// Null values are written as empty cells, as postgres \copy ... CSV does.
+type CSVSerializer struct {
+	Columns []string   // json field names in output order; nil means every field in struct order
+	Bools   BoolFormat // how bool cells are written
+}
+
+func (CSVSerializer) Extension() string { return ".csv" }
+
+func (s CSVSerializer) Serialize(views []LanguageCandidate) ([]byte, error) {
+	if s.Columns != nil {
+		if _, err := Project(nil, s.Columns); err != nil {
+			return nil, err
+		}
+	}
+
+	header := s.Columns
+	if header == nil {
+		for _, nv := range recordValues(&LanguageCandidate{}) {
+			header = append(header, nv.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(header)
+	for i := range views {
+		values := recordValues(&views[i])
+		if s.Columns != nil {
+			values = selectValues(values, s.Columns)
+		}
+		row := make([]string, len(values))
+		for j, nv := range values {
+			switch v := nv.Value.(type) {
+			case nil:
+				row[j] = ""
+			case bool:
+				row[j] = s.Bools.text(v)
+			default:
+				row[j] = displayValue(v)
+			}
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// selectValues picks the named values, in the order of names
+func selectValues(values []namedValue, names []string) []namedValue {
+	byName := make(map[string]any, len(values))
+	for _, nv := range values {
+		byName[nv.Name] = nv.Value
+	}
+	selected := make([]namedValue, len(names))
+	for i, name := range names {
+		selected[i] = namedValue{Name: name, Value: byName[name]}
+	}
+	return selected
+}
+
+// YAMLSerializer writes a YAML sequence of mappings; strings are double-quoted
+type YAMLSerializer struct{}
+
+func (YAMLSerializer) Extension() string { return ".yaml" }
+
+func (YAMLSerializer) Serialize(views []LanguageCandidate) ([]byte, error) {
+	var buf bytes.Buffer
+	if len(views) == 0 {
+		buf.WriteString("[]\n")
+	}
+	for i := range views {
+		for j, nv := range recordValues(&views[i]) {
+			prefix := "  "
+			if j == 0 {
+				prefix = "- "
+			}
+			fmt.Fprintf(&buf, "%s%s: %s\n", prefix, nv.Name, yamlScalar(nv.Value))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlScalar renders a field value as a YAML scalar
+func yamlScalar(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return strconv.Quote(t)
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// ProjectionSerializer writes a JSON array holding only Fields of each candidate
+type ProjectionSerializer struct {
+	Fields []string
+}
+
+func (ProjectionSerializer) Extension() string { return ".json" }
+
+func (p ProjectionSerializer) Serialize(views []LanguageCandidate) ([]byte, error) {
+	rows, err := Project(views, p.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(rows, "", "  ")
+}
+
+// ProtoSerializer writes each candidate as an erb.LanguageCandidate message
+// (MarshalCandidateViewProto), preceded by its length as a varint: the
+// delimited stream protobuf libraries read with parseDelimitedFrom
+type ProtoSerializer struct{}
+
+func (ProtoSerializer) Extension() string { return ".pb" }
+
+func (ProtoSerializer) Serialize(views []LanguageCandidate) ([]byte, error) {
+	var buf []byte
+	for i := range views {
+		msg, err := MarshalCandidateViewProto(views[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", views[i].LanguageCandidateId, err)
+		}
+		buf = binary.AppendUvarint(buf, uint64(len(msg)))
+		buf = append(buf, msg...)
+	}
+	return buf, nil
+}
+
+// SaveWith serializes views with s and writes them to path
+func SaveWith(path string, views []LanguageCandidate, s Serializer) error {
+	if err := checkOutputPath(path); err != nil {
+		return err
+	}
+
+	data, err := s.Serialize(views)
+	if err != nil {
+		return fmt.Errorf("failed to serialize records: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+
+	return nil
+}
+
+// OutputTarget is one file to write a computed slice to
+type OutputTarget struct {
+	Path       string
+	Serializer Serializer
+}
+
+// SaveAll writes the same computed slice to every target, stopping at the first error
+func SaveAll(views []LanguageCandidate, targets []OutputTarget) error {
+	for _, t := range targets {
+		if err := SaveWith(t.Path, views, t.Serializer); err != nil {
+			return fmt.Errorf("%s: %w", t.Path, err)
+		}
+	}
+	return nil
+}
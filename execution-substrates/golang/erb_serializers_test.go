@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveWithJSONAndYAML(t *testing.T) {
+	mug := candidateByID(t, computedCandidates(t), "a-coffee-mug")
+	dir := t.TempDir()
+
+	for _, s := range []Serializer{JSONSerializer{}, YAMLSerializer{}} {
+		path := filepath.Join(dir, "answers"+s.Extension())
+		if err := SaveWith(path, []LanguageCandidate{mug}, s); err != nil {
+			t.Fatalf("SaveWith(%T): %v", s, err)
+		}
+	}
+
+	var decoded []LanguageCandidate
+	if err := json.Unmarshal(readFile(t, filepath.Join(dir, "answers.json")), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffTestCandidates([]LanguageCandidate{mug}, decoded); len(diffs) > 0 {
+		t.Errorf("answers.json differs from the saved candidate: %+v", diffs)
+	}
+
+	yaml := string(readFile(t, filepath.Join(dir, "answers.yaml")))
+	if !strings.HasPrefix(yaml, `- language_candidate_id: "a-coffee-mug"`+"\n") {
+		t.Errorf("answers.yaml starts %q", yaml[:min(len(yaml), 60)])
+	}
+	if !strings.Contains(yaml, "\n  prediction_fail: null\n") {
+		t.Errorf("answers.yaml has no null prediction_fail:\n%s", yaml)
+	}
+}
+
+func TestCSVSerializerWritesNullAsEmptyCell(t *testing.T) {
+	saved := NullDisplay
+	t.Cleanup(func() { NullDisplay = saved })
+	NullDisplay = "N/A"
+
+	mug := candidateByID(t, computedCandidates(t), "a-coffee-mug")
+	data, err := CSVSerializer{Columns: []string{"language_candidate_id", "prediction_fail", "has_syntax"}}.Serialize([]LanguageCandidate{mug})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "language_candidate_id,prediction_fail,has_syntax\na-coffee-mug,,false\n"
+	if got := string(data); got != want {
+		t.Errorf("CSV = %q, want %q", got, want)
+	}
+	if strings.Contains(string(data), "N/A") {
+		t.Error("CSV uses NullDisplay")
+	}
+}
+
+func TestCSVSerializerWritesHeaderForNoRecords(t *testing.T) {
+	data, err := CSVSerializer{}.Serialize(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, nv := range recordValues(&LanguageCandidate{}) {
+		names = append(names, nv.Name)
+	}
+	if want := strings.Join(names, ",") + "\n"; string(data) != want {
+		t.Errorf("CSV of no records = %q, want the header row %q", data, want)
+	}
+
+	data, err = CSVSerializer{Columns: []string{"name", "predicted_answer"}}.Serialize([]LanguageCandidate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "name,predicted_answer\n" {
+		t.Errorf("CSV of no records with Columns = %q", got)
+	}
+}
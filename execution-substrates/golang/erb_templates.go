@@ -0,0 +1,89 @@
+// ERB SDK - Candidate Templates (hand-written)
+// ============================================
+// Lets an input file keep candidates DRY: a candidate with a template_id
+// inherits every raw field it leaves null from the candidate with that
+// language_candidate_id. template_id is an input-only field, so it lives on
+// TemplatedCandidate rather than in the rulebook-generated struct.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TemplatedCandidate is a LanguageCandidate that may name a template
+type TemplatedCandidate struct {
+	LanguageCandidate
+	TemplateId *string `json:"template_id"`
+}
+
+// LoadTemplatedCandidates loads candidates that may carry a template_id
+func LoadTemplatedCandidates(path string) ([]TemplatedCandidate, error) {
+	return LoadRecords[TemplatedCandidate](path)
+}
+
+// ResolveTemplates fills each candidate's null raw fields from its template,
+// following template chains, and returns the candidates ready to compute.
+// Templates are candidates too and stay in the result. Unknown template ids
+// and template cycles are errors.
+func ResolveTemplates(candidates []TemplatedCandidate) ([]LanguageCandidate, error) {
+	byId := make(map[string]int, len(candidates))
+	for i := range candidates {
+		byId[candidates[i].LanguageCandidateId] = i
+	}
+
+	const (
+		unvisited = iota
+		resolving
+		resolved
+	)
+	state := make([]int, len(candidates))
+	result := make([]LanguageCandidate, len(candidates))
+
+	var resolve func(i int, chain []string) error
+	resolve = func(i int, chain []string) error {
+		switch state[i] {
+		case resolved:
+			return nil
+		case resolving:
+			return fmt.Errorf("template cycle: %v", append(chain, candidates[i].LanguageCandidateId))
+		}
+		state[i] = resolving
+		chain = append(chain, candidates[i].LanguageCandidateId)
+
+		result[i] = candidates[i].LanguageCandidate
+		if tid := candidates[i].TemplateId; tid != nil && *tid != "" {
+			t, ok := byId[*tid]
+			if !ok {
+				return fmt.Errorf("%s: unknown template %q", candidates[i].LanguageCandidateId, *tid)
+			}
+			if err := resolve(t, chain); err != nil {
+				return err
+			}
+			inheritRawFields(&result[i], &result[t])
+		}
+
+		state[i] = resolved
+		return nil
+	}
+
+	for i := range candidates {
+		if err := resolve(i, nil); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// inheritRawFields copies each raw field that is null on lc from template
+func inheritRawFields(lc, template *LanguageCandidate) {
+	fields := fieldsByJSONName(lc)
+	inherited := fieldsByJSONName(template)
+	for _, name := range LanguageCandidateRawFields {
+		f := fields[name]
+		if f.Kind() == reflect.Pointer && f.IsNil() {
+			f.Set(inherited[name])
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResolveTemplatesInheritsAndOverrides(t *testing.T) {
+	base := candidateByID(t, loadTestRulebook(t).LanguageCandidates, "python")
+	candidates := []TemplatedCandidate{
+		{LanguageCandidate: LanguageCandidate{LanguageCandidateId: "go", Name: ptr("Go")}, TemplateId: ptr("python")},
+		{LanguageCandidate: base},
+		{LanguageCandidate: LanguageCandidate{LanguageCandidateId: "held-go", CanBeHeld: ptr(true)}, TemplateId: ptr("go")},
+	}
+
+	resolved, err := ResolveTemplates(candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	goLang, heldGo := resolved[0], resolved[2]
+	if stringVal(goLang.Name) != "Go" || stringVal(goLang.Category) != stringVal(base.Category) || !boolVal(goLang.HasSyntax) {
+		t.Errorf("go = name %q category %q has_syntax %s, want Go overriding the python template", stringVal(goLang.Name), stringVal(goLang.Category), show3(goLang.HasSyntax))
+	}
+	if goLang.LanguageCandidateId != "go" {
+		t.Errorf("go inherited id %q", goLang.LanguageCandidateId)
+	}
+	if stringVal(heldGo.Name) != "Go" || !boolVal(heldGo.CanBeHeld) || boolVal(heldGo.ComputeAll().PredictedAnswer) {
+		t.Errorf("held-go = name %q can_be_held %s, want Go through the chain and its own can_be_held", stringVal(heldGo.Name), show3(heldGo.CanBeHeld))
+	}
+	if !boolVal(goLang.ComputeAll().PredictedAnswer) {
+		t.Error("go does not compute as a top answer like its template")
+	}
+}
+
+func TestResolveTemplatesRejectsCyclesAndUnknownTemplates(t *testing.T) {
+	cycle := []TemplatedCandidate{
+		{LanguageCandidate: LanguageCandidate{LanguageCandidateId: "a"}, TemplateId: ptr("b")},
+		{LanguageCandidate: LanguageCandidate{LanguageCandidateId: "b"}, TemplateId: ptr("a")},
+	}
+	if _, err := ResolveTemplates(cycle); err == nil || !strings.Contains(err.Error(), "template cycle: [a b a]") {
+		t.Errorf("ResolveTemplates with a cycle = %v, want a template cycle error", err)
+	}
+
+	unknown := []TemplatedCandidate{{LanguageCandidate: LanguageCandidate{LanguageCandidateId: "a"}, TemplateId: ptr("missing")}}
+	if _, err := ResolveTemplates(unknown); err == nil {
+		t.Error("ResolveTemplates with an unknown template = nil, want an error")
+	}
+}
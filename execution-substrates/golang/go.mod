@@ -0,0 +1,3 @@
+module github.com/eejai42/is-everything-really-a-language/execution-substrates/golang
+
+go 1.23
@@ -10,28 +10,44 @@ it reads whatever tables and schemas are defined and generates corresponding Go
 
 Generated files:
 - erb_sdk.go - Structs, individual Calc* methods, and ComputeAll functions
+- erb_sdk.proto - proto3 messages mirroring the structs (encoded by erb_proto.go)
 - main.go - Test runner for the primary table (LanguageCandidates)
 """
 
 import sys
 import re
+import hashlib
 from pathlib import Path
-from typing import Dict, List, Any, Set
+from typing import Dict, List, Any
 
 # Add project root to path for shared imports
 sys.path.insert(0, str(Path(__file__).resolve().parent.parent.parent))
 
-from orchestration.shared import load_rulebook, get_candidate_name_from_cwd, handle_clean_arg
+from orchestration.shared import load_rulebook, get_rulebook_path, get_candidate_name_from_cwd, handle_clean_arg
 from orchestration.formula_parser import (
     parse_formula, compile_to_go, get_field_dependencies,
     to_snake_case, to_pascal_case, ASTNode
 )
 
 
+# The generator's own sources (relative to this directory, '/'-separated).
+# Their sha256 is embedded next to the rulebook's, so erb_sdk.go also goes
+# stale when the generator changes.
+GENERATOR_SOURCES = ['inject-into-golang.py', '../../orchestration/formula_parser.py']
+
+
 # =============================================================================
 # UTILITY FUNCTIONS (Domain-agnostic helpers)
 # =============================================================================
 
+
+def generator_checksum(script_dir: Path) -> str:
+    """sha256 hex of the GENERATOR_SOURCES files, concatenated in order."""
+    h = hashlib.sha256()
+    for source in GENERATOR_SOURCES:
+        h.update((script_dir / source).read_bytes())
+    return h.hexdigest()
+
 def get_table_names(rulebook: Dict) -> List[str]:
     """Extract table names from the rulebook (excluding metadata keys).
 
@@ -86,47 +102,6 @@ def table_name_to_struct_name(table_name: str) -> str:
     return table_name
 
 
-def build_dag_levels(calculated_fields: List[Dict], raw_field_names: Set[str]) -> List[List[Dict]]:
-    """Build DAG levels for calculated fields based on dependencies.
-
-    This ensures fields are computed in the correct order - fields that depend
-    on other calculated fields are placed in later levels.
-    """
-    field_deps = {}
-    for field in calculated_fields:
-        formula = field.get('formula', '')
-        try:
-            ast = parse_formula(formula)
-            deps = get_field_dependencies(ast)
-            field_deps[field['name']] = set(d for d in deps)
-        except Exception as e:
-            print(f"Warning: Failed to parse formula for {field['name']}: {e}")
-            field_deps[field['name']] = set()
-
-    levels = []
-    assigned = set(raw_field_names)
-    remaining = {f['name']: f for f in calculated_fields}
-
-    while remaining:
-        current_level = []
-        for name, field in list(remaining.items()):
-            deps = field_deps.get(name, set())
-            if deps <= assigned:
-                current_level.append(field)
-
-        if not current_level:
-            print(f"Warning: Could not resolve dependencies for: {list(remaining.keys())}")
-            levels.append(list(remaining.values()))
-            break
-
-        levels.append(current_level)
-        for field in current_level:
-            assigned.add(field['name'])
-            del remaining[field['name']]
-
-    return levels
-
-
 # =============================================================================
 # CODE GENERATION FUNCTIONS
 # =============================================================================
@@ -141,7 +116,7 @@ def generate_struct_field(field: Dict) -> str:
     return f'\t{name} {go_type} `json:"{json_tag}"`'
 
 
-def compile_formula_to_go(field: Dict, struct_var: str = 'tc', calc_vars: Dict[str, str] = None,
+def compile_formula_to_go(field: Dict, struct_var: str = 'tc',
                           field_types: Dict[str, str] = None) -> str:
     """Compile a field's formula to a Go expression.
 
@@ -150,8 +125,6 @@ def compile_formula_to_go(field: Dict, struct_var: str = 'tc', calc_vars: Dict[s
     Args:
         field: The field definition with 'formula' key
         struct_var: Variable name for the struct (e.g., 'tc' for tc.FieldName)
-        calc_vars: Dict mapping calculated field names to their local variable names.
-                   Used to substitute struct field refs with local vars for deps.
         field_types: Dict mapping field names to their datatypes (e.g., {'OrderNumber': 'integer'})
     """
     formula = field.get('formula', '')
@@ -159,31 +132,6 @@ def compile_formula_to_go(field: Dict, struct_var: str = 'tc', calc_vars: Dict[s
         ast = parse_formula(formula)
         go_expr = compile_to_go(ast, struct_var, field_types or {})
 
-        # Substitute references to already-computed calculated fields
-        # with their local variable names. Order matters - more specific patterns first.
-        if calc_vars:
-            for field_name, var_name in calc_vars.items():
-                # Pattern 1: boolVal(tc.Field) -> var_name (already a bool)
-                go_expr = go_expr.replace(f'boolVal({struct_var}.{field_name})', var_name)
-
-                # Pattern 2: stringVal(tc.Field) -> var_name (already a string)
-                go_expr = go_expr.replace(f'stringVal({struct_var}.{field_name})', var_name)
-
-                # Pattern 3: (tc.Field != nil && *tc.Field == X) -> (var_name == boolVal(X))
-                def wrap_rhs_in_boolval(match):
-                    rhs = match.group(1)
-                    if rhs.startswith(f'{struct_var}.'):
-                        return f'({var_name} == boolVal({rhs}))'
-                    return f'({var_name} == {rhs})'
-                pattern = rf'\({struct_var}\.{field_name} != nil && \*{struct_var}\.{field_name} == ([^)]+)\)'
-                go_expr = re.sub(pattern, wrap_rhs_in_boolval, go_expr)
-
-                # Pattern 4: tc.Field != nil && *tc.Field (without == ) -> var_name
-                go_expr = go_expr.replace(f'{struct_var}.{field_name} != nil && *{struct_var}.{field_name}', var_name)
-
-                # Pattern 5: Any remaining tc.Field -> var_name
-                go_expr = go_expr.replace(f'{struct_var}.{field_name}', var_name)
-
         # Fix IF conditions with pointer fields: `if tc.Field {` -> `if boolVal(tc.Field) {`
         go_expr = re.sub(rf'if ({struct_var}\.\w+) \{{', r'if boolVal(\1) {', go_expr)
 
@@ -226,71 +174,59 @@ def generate_calc_function(field: Dict, struct_name: str, struct_var: str = 'tc'
 
 def generate_compute_all_function(
     struct_name: str,
-    raw_fields: List[Dict],
     calculated_fields: List[Dict],
-    dag_levels: List[List[Dict]],
-    struct_var: str = 'tc',
-    field_types: Dict[str, str] = None
+    struct_var: str = 'tc'
 ) -> List[str]:
-    """Generate the ComputeAll function that computes all calculated fields.
+    """Generate the <Struct>ComputeGraph and the ComputeAll function that drives it.
 
-    This function calls each individual Calc* method in DAG order and returns
-    a new struct with all fields populated.
+    Each graph step calls one Calc* method and stores the result on the
+    record (Calc* methods read dependencies from the struct). The evaluation
+    order is sorted from <Struct>FieldDeps at runtime, so it is data rather
+    than a hard-coded sequence.
     """
     lines = []
 
-    lines.append('// ComputeAll computes all calculated fields and returns an updated struct')
-    lines.append(f'func ({struct_var} *{struct_name}) ComputeAll() *{struct_name} {{')
-
-    # Generate calls to each Calc* function in DAG order
-    calc_vars = {}  # Track variable names for calculated fields
-    for level_idx, level_fields in enumerate(dag_levels):
-        lines.append(f'\t// Level {level_idx + 1} calculations')
-        for field in level_fields:
-            name = field['name']
-            var_name = name[0].lower() + name[1:]  # camelCase
-
-            # For level 1, call the Calc method directly
-            # For level 2+, we need to pass already-computed values
-            # But since Calc* methods read from struct, we need to update struct first
-            # Actually, let's compute inline for proper dependency handling
-            go_expr = compile_formula_to_go(field, struct_var, calc_vars, field_types)
-            lines.append(f'\t{var_name} := {go_expr}')
-
-            calc_vars[name] = var_name
-        lines.append('')
-
-    # Generate return struct with all fields
-    lines.append(f'\treturn &{struct_name}{{')
-    for field in raw_fields:
-        name = field['name']
-        lines.append(f'\t\t{name}: {struct_var}.{name},')
+    lines.append(f'// {struct_name}ComputeGraph evaluates the calculated fields of a {struct_name} in dependency order')
+    lines.append(f'var {struct_name}ComputeGraph = mustComputeGraph({struct_name}CalculatedFields, {struct_name}FieldDeps, map[string]func({struct_var} *{struct_name}){{')
     for field in calculated_fields:
         name = field['name']
-        var_name = calc_vars[name]
         datatype = field.get('datatype', 'string')
         # Use nilIfEmpty for string fields to return null for empty strings
         if datatype == 'string' or datatype not in ('boolean', 'integer'):
-            lines.append(f'\t\t{name}: nilIfEmpty({var_name}),')
+            store = f'{struct_var}.{name} = nilIfEmpty({struct_var}.Calc{name}())'
         else:
-            lines.append(f'\t\t{name}: &{var_name},')
-    lines.append('\t}')
+            store = f'v := {struct_var}.Calc{name}(); {struct_var}.{name} = &v'
+        lines.append(f'\t"{to_snake_case(name)}": func({struct_var} *{struct_name}) {{ {store} }},')
+    lines.append('})')
+    lines.append('')
+    lines.append('// ComputeAll computes all calculated fields and returns an updated struct')
+    lines.append(f'func ({struct_var} *{struct_name}) ComputeAll() *{struct_name} {{')
+    lines.append(f'\tresult := *{struct_var}')
+    lines.append(f'\t{struct_name}ComputeGraph.Evaluate(&result)')
+    lines.append('\treturn &result')
     lines.append('}')
 
     return lines
 
 
+def get_struct_fields(schema: List[Dict]) -> List[Dict]:
+    """Return a table's fields in struct order: raw fields, then calculated fields.
+
+    Calculated fields override raw fields with the same name.
+    """
+    raw_fields = get_raw_fields(schema)
+    calculated_fields = get_calculated_fields(schema)
+    calculated_names = {f['name'] for f in calculated_fields}
+    return [f for f in raw_fields if f['name'] not in calculated_names] + calculated_fields
+
+
 def generate_struct_for_table(table_name: str, schema: List[Dict]) -> List[str]:
     """Generate the struct definition for a table."""
     lines = []
     struct_name = table_name_to_struct_name(table_name)
 
-    raw_fields = get_raw_fields(schema)
-    calculated_fields = get_calculated_fields(schema)
-
     # Struct needs all fields - calculated fields override raw fields with same name
-    calculated_names = {f['name'] for f in calculated_fields}
-    all_fields = [f for f in raw_fields if f['name'] not in calculated_names] + calculated_fields
+    all_fields = get_struct_fields(schema)
 
     lines.append(f'// {struct_name} represents a row in the {table_name} table')
     lines.append(f'type {struct_name} struct {{')
@@ -301,6 +237,30 @@ def generate_struct_for_table(table_name: str, schema: List[Dict]) -> List[str]:
     return lines
 
 
+def generate_field_lists(table_name: str, raw_fields: List[Dict], calculated_fields: List[Dict]) -> List[str]:
+    """Generate the <Struct>RawFields and <Struct>CalculatedFields slices.
+
+    Both list json (snake_case) field names in schema order.
+    """
+    lines = []
+    struct_name = table_name_to_struct_name(table_name)
+    calculated_names = {f['name'] for f in calculated_fields}
+    raw_fields = [f for f in raw_fields if f['name'] not in calculated_names]
+
+    for kind, fields in (('Raw', raw_fields), ('Calculated', calculated_fields)):
+        lines.append(f'// {struct_name}{kind}Fields lists the {kind.lower()} fields of the {table_name} table (json names, schema order)')
+        if not fields:
+            lines.append(f'var {struct_name}{kind}Fields = []string{{}}')
+        else:
+            lines.append(f'var {struct_name}{kind}Fields = []string{{')
+            for field in fields:
+                lines.append(f'\t"{to_snake_case(field["name"])}",')
+            lines.append('}')
+        lines.append('')
+
+    return lines[:-1]
+
+
 def generate_table_sdk(table_name: str, table_data: Dict) -> List[str]:
     """Generate complete SDK code for a single table.
 
@@ -315,7 +275,6 @@ def generate_table_sdk(table_name: str, table_data: Dict) -> List[str]:
 
     raw_fields = get_raw_fields(schema)
     calculated_fields = get_calculated_fields(schema)
-    raw_field_names = {f['name'] for f in raw_fields}
 
     # Build field types map for type-aware code generation
     field_types = build_field_types(schema)
@@ -330,10 +289,11 @@ def generate_table_sdk(table_name: str, table_data: Dict) -> List[str]:
     lines.extend(generate_struct_for_table(table_name, schema))
     lines.append('')
 
-    if calculated_fields:
-        # Build DAG for calculation ordering
-        dag_levels = build_dag_levels(calculated_fields, raw_field_names)
+    # Field lists (json names) so hand-written code can tell raw from calculated
+    lines.extend(generate_field_lists(table_name, raw_fields, calculated_fields))
+    lines.append('')
 
+    if calculated_fields:
         # Individual Calc* functions
         lines.append(f'// --- Individual Calculation Functions ---')
         lines.append('')
@@ -341,22 +301,139 @@ def generate_table_sdk(table_name: str, table_data: Dict) -> List[str]:
             lines.extend(generate_calc_function(field, struct_name, field_types=field_types))
             lines.append('')
 
+        # Formula dependencies keyed by json name
+        lines.append(f'// {struct_name}FieldDeps maps each calculated field to the fields its formula references (json names)')
+        lines.append(f'var {struct_name}FieldDeps = map[string][]string{{')
+        for field in calculated_fields:
+            try:
+                deps = get_field_dependencies(parse_formula(field.get('formula', '')))
+            except Exception:
+                deps = []
+            dep_list = ', '.join(f'"{to_snake_case(d)}"' for d in deps)
+            lines.append(f'\t"{to_snake_case(field["name"])}": {{{dep_list}}},')
+        lines.append('}')
+        lines.append('')
+
+        # Calc* dispatch table keyed by json name
+        lines.append(f'// {struct_name}CalcFuncs maps calculated field json names to their Calc* methods')
+        lines.append(f'var {struct_name}CalcFuncs = map[string]func(tc *{struct_name}) any{{')
+        for field in calculated_fields:
+            lines.append(f'\t"{to_snake_case(field["name"])}": func(tc *{struct_name}) any {{ return tc.Calc{field["name"]}() }},')
+        lines.append('}')
+        lines.append('')
+
         # ComputeAll function
         lines.append(f'// --- Compute All Calculated Fields ---')
         lines.append('')
-        lines.extend(generate_compute_all_function(
-            struct_name, raw_fields, calculated_fields, dag_levels, field_types=field_types
-        ))
+        lines.extend(generate_compute_all_function(struct_name, calculated_fields))
         lines.append('')
 
+    # Record interface
+    lines.append(f'// TableName returns the rulebook table name for {struct_name} records')
+    lines.append(f'func (tc *{struct_name}) TableName() string {{')
+    lines.append(f'\treturn "{table_name}"')
+    lines.append('}')
+    lines.append('')
+    if calculated_fields:
+        lines.append('// Compute returns the record with all calculated fields computed')
+        lines.append(f'func (tc *{struct_name}) Compute() Record {{')
+        lines.append('\treturn tc.ComputeAll()')
+    else:
+        lines.append('// Compute returns the record unchanged (the table has no calculated fields)')
+        lines.append(f'func (tc *{struct_name}) Compute() Record {{')
+        lines.append('\treturn tc')
+    lines.append('}')
+    lines.append('')
+
     return lines
 
 
-def generate_erb_sdk(rulebook: Dict) -> str:
+def generate_compute_graph_type() -> List[str]:
+    """Generate the table-agnostic ComputeGraph type.
+
+    ComputeGraph topologically sorts calculated fields by their declared
+    dependencies when the package initializes, and reports cycles as an error.
+    """
+    return """// ComputeGraph evaluates a record's calculated fields in dependency order.
+// The order is sorted at runtime from each field's declared dependencies,
+// so a new calculated field never requires reordering code by hand.
+type ComputeGraph[T any] struct {
+	Order []string // calculated field json names in evaluation order
+	steps map[string]func(record *T)
+}
+
+// NewComputeGraph topologically sorts fields by deps (ties keep the order
+// of fields) and returns an error naming the fields caught in a cycle.
+// Each step computes one field and stores it on the record.
+func NewComputeGraph[T any](fields []string, deps map[string][]string, steps map[string]func(record *T)) (*ComputeGraph[T], error) {
+	pending := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if steps[f] == nil {
+			return nil, fmt.Errorf("calculated field %s has no compute step", f)
+		}
+		pending[f] = true
+	}
+
+	g := &ComputeGraph[T]{steps: steps}
+	for len(g.Order) < len(fields) {
+		progressed := false
+		for _, f := range fields {
+			if !pending[f] {
+				continue
+			}
+			ready := true
+			for _, d := range deps[f] {
+				if pending[d] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				g.Order = append(g.Order, f)
+				pending[f] = false
+				progressed = true
+			}
+		}
+		if !progressed {
+			var cycle []string
+			for _, f := range fields {
+				if pending[f] {
+					cycle = append(cycle, f)
+				}
+			}
+			return nil, fmt.Errorf("calculated fields form a dependency cycle: %v", cycle)
+		}
+	}
+	return g, nil
+}
+
+// mustComputeGraph is NewComputeGraph for the package-level graphs: a cycle
+// in the rulebook fails loudly at startup
+func mustComputeGraph[T any](fields []string, deps map[string][]string, steps map[string]func(record *T)) *ComputeGraph[T] {
+	g, err := NewComputeGraph(fields, deps, steps)
+	if err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Evaluate computes every calculated field of record in Order
+func (g *ComputeGraph[T]) Evaluate(record *T) {
+	for _, f := range g.Order {
+		g.steps[f](record)
+	}
+}""".split('\n')
+
+
+def generate_erb_sdk(rulebook: Dict, rulebook_checksum: str, generator_sum: str) -> str:
     """Generate the complete erb_sdk.go content.
 
     This function is domain-agnostic - it reads whatever tables are defined
     in the rulebook and generates corresponding Go code for all of them.
+    rulebook_checksum (sha256 hex of the rulebook file) and generator_sum
+    (generator_checksum) are embedded in the banner and as
+    GeneratedRulebookChecksum / GeneratedGeneratorChecksum so stale code
+    can be detected.
     """
     lines = []
 
@@ -364,6 +441,8 @@ def generate_erb_sdk(rulebook: Dict) -> str:
     lines.append('// ERB SDK - Go Implementation (GENERATED - DO NOT EDIT)')
     lines.append('// ======================================================')
     lines.append('// Generated from: effortless-rulebook/effortless-rulebook.json')
+    lines.append(f'// Rulebook sha256: {rulebook_checksum}')
+    lines.append(f'// Generator sha256: {generator_sum}')
     lines.append('//')
     lines.append('// This file contains structs and calculation functions')
     lines.append('// for all tables defined in the rulebook.')
@@ -374,9 +453,22 @@ def generate_erb_sdk(rulebook: Dict) -> str:
     lines.append('\t"encoding/json"')
     lines.append('\t"fmt"')
     lines.append('\t"os"')
+    lines.append('\t"path/filepath"')
     lines.append('\t"strconv"')
     lines.append(')')
     lines.append('')
+    lines.append('// GeneratedRulebookChecksum is the sha256 of the rulebook this file was generated from')
+    lines.append(f'const GeneratedRulebookChecksum = "{rulebook_checksum}"')
+    lines.append('')
+    lines.append('// GeneratedGeneratorChecksum is the sha256 of the GeneratorSources, concatenated, that generated this file')
+    lines.append(f'const GeneratedGeneratorChecksum = "{generator_sum}"')
+    lines.append('')
+    lines.append('// GeneratorSources are the generator\'s own source files, relative to this substrate directory')
+    sources = ', '.join(f'"{source}"' if '/' not in source else
+                        'filepath.Join(' + ', '.join(f'"{part}"' for part in source.split('/')) + ')'
+                        for source in GENERATOR_SOURCES)
+    lines.append(f'var GeneratorSources = []string{{{sources}}}')
+    lines.append('')
 
     # Helper functions
     lines.append('// =============================================================================')
@@ -424,6 +516,39 @@ def generate_erb_sdk(rulebook: Dict) -> str:
     lines.append('}')
     lines.append('')
 
+    # Output path guard used by every save function
+    lines.append('// OutputDenylist holds filepath.Match patterns (matched against the base name) that save functions refuse to write')
+    lines.append('var OutputDenylist = []string{"*.go"}')
+    lines.append('')
+    lines.append('// ForceOverwrite lets save functions write to denylisted paths (the -force flag)')
+    lines.append('var ForceOverwrite = false')
+    lines.append('')
+    lines.append('// checkOutputPath refuses denylisted output paths unless ForceOverwrite is set')
+    lines.append('func checkOutputPath(path string) error {')
+    lines.append('\tif ForceOverwrite {')
+    lines.append('\t\treturn nil')
+    lines.append('\t}')
+    lines.append('\tfor _, pattern := range OutputDenylist {')
+    lines.append('\t\tif ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {')
+    lines.append('\t\t\treturn fmt.Errorf("refusing to overwrite %s: matches protected pattern %q (use -force)", path, pattern)')
+    lines.append('\t\t}')
+    lines.append('\t}')
+    lines.append('\treturn nil')
+    lines.append('}')
+    lines.append('')
+
+    # Common interface implemented by every table struct
+    lines.append('// Record is implemented by every table struct so mixed batches can be processed uniformly')
+    lines.append('type Record interface {')
+    lines.append('\tTableName() string')
+    lines.append('\tCompute() Record')
+    lines.append('}')
+    lines.append('')
+
+    # Runtime dependency graph shared by every table's ComputeAll
+    lines.extend(generate_compute_graph_type())
+    lines.append('')
+
     # Get all table names from the rulebook (domain-agnostic discovery)
     table_names = get_table_names(rulebook)
 
@@ -452,41 +577,105 @@ def generate_erb_sdk(rulebook: Dict) -> str:
         lines.append('// =============================================================================')
         lines.append('')
 
+        lines.append('// LoadRecords loads a JSON array of records of any table from a file')
+        lines.append('func LoadRecords[T any](path string) ([]T, error) {')
+        lines.append('\tdata, err := os.ReadFile(path)')
+        lines.append('\tif err != nil {')
+        lines.append('\t\treturn nil, fmt.Errorf("failed to read file: %w", err)')
+        lines.append('\t}')
+        lines.append('')
+        lines.append('\tvar records []T')
+        lines.append('\tif err := json.Unmarshal(data, &records); err != nil {')
+        lines.append('\t\treturn nil, fmt.Errorf("failed to parse file: %w", err)')
+        lines.append('\t}')
+        lines.append('')
+        lines.append('\treturn records, nil')
+        lines.append('}')
+        lines.append('')
+        lines.append('// SaveRecords saves records of any table to a JSON file as a 2-space indented array')
+        lines.append('func SaveRecords[T any](path string, records []T) error {')
+        lines.append('\tif err := checkOutputPath(path); err != nil {')
+        lines.append('\t\treturn err')
+        lines.append('\t}')
+        lines.append('')
+        lines.append('\tdata, err := json.MarshalIndent(records, "", "  ")')
+        lines.append('\tif err != nil {')
+        lines.append('\t\treturn fmt.Errorf("failed to marshal records: %w", err)')
+        lines.append('\t}')
+        lines.append('')
+        lines.append('\tif err := os.WriteFile(path, data, 0644); err != nil {')
+        lines.append('\t\treturn fmt.Errorf("failed to write records: %w", err)')
+        lines.append('\t}')
+        lines.append('')
+        lines.append('\treturn nil')
+        lines.append('}')
+        lines.append('')
+
         for table_name in tables_with_calc:
             struct_name = table_name_to_struct_name(table_name)
             lines.append(f'// Load{struct_name}Records loads {table_name} records from a JSON file')
             lines.append(f'func Load{struct_name}Records(path string) ([]{struct_name}, error) {{')
-            lines.append('\tdata, err := os.ReadFile(path)')
-            lines.append('\tif err != nil {')
-            lines.append('\t\treturn nil, fmt.Errorf("failed to read file: %w", err)')
-            lines.append('\t}')
-            lines.append('')
-            lines.append(f'\tvar records []{struct_name}')
-            lines.append('\tif err := json.Unmarshal(data, &records); err != nil {')
-            lines.append('\t\treturn nil, fmt.Errorf("failed to parse file: %w", err)')
-            lines.append('\t}')
-            lines.append('')
-            lines.append('\treturn records, nil')
+            lines.append(f'\treturn LoadRecords[{struct_name}](path)')
             lines.append('}')
             lines.append('')
             lines.append(f'// Save{struct_name}Records saves computed {table_name} records to a JSON file')
             lines.append(f'func Save{struct_name}Records(path string, records []{struct_name}) error {{')
-            lines.append('\tdata, err := json.MarshalIndent(records, "", "  ")')
-            lines.append('\tif err != nil {')
-            lines.append('\t\treturn fmt.Errorf("failed to marshal records: %w", err)')
-            lines.append('\t}')
-            lines.append('')
-            lines.append('\tif err := os.WriteFile(path, data, 0644); err != nil {')
-            lines.append('\t\treturn fmt.Errorf("failed to write records: %w", err)')
-            lines.append('\t}')
-            lines.append('')
-            lines.append('\treturn nil')
+            lines.append('\treturn SaveRecords(path, records)')
             lines.append('}')
             lines.append('')
 
     return '\n'.join(lines)
 
 
+def datatype_to_proto(datatype: str) -> str:
+    """Convert rulebook datatype to proto3 scalar type."""
+    dt = datatype.lower()
+    if dt == 'boolean':
+        return 'bool'
+    elif dt == 'integer':
+        return 'int64'
+    else:
+        return 'string'
+
+
+def generate_erb_sdk_proto(rulebook: Dict, rulebook_checksum: str) -> str:
+    """Generate erb_sdk.proto: one proto3 message per table struct.
+
+    Field numbers follow struct order (1, 2, ...), which is what the
+    hand-written encoder in erb_proto.go relies on. Nullable fields are
+    `optional` so a nil pointer and a zero value stay distinct on the wire.
+    """
+    lines = []
+    lines.append('// ERB SDK - Protocol Buffers schema (GENERATED - DO NOT EDIT)')
+    lines.append('// ===========================================================')
+    lines.append('// Generated from: effortless-rulebook/effortless-rulebook.json')
+    lines.append(f'// Rulebook sha256: {rulebook_checksum}')
+    lines.append('//')
+    lines.append('// Field numbers follow the Go struct field order, so they change when')
+    lines.append('// the rulebook schema does. Encoded by erb_proto.go.')
+    lines.append('')
+    lines.append('syntax = "proto3";')
+    lines.append('')
+    lines.append('package erb;')
+
+    for table_name in get_table_names(rulebook):
+        table_data = rulebook[table_name]
+        if not isinstance(table_data, dict) or 'schema' not in table_data:
+            continue
+
+        lines.append('')
+        lines.append(f'// {table_name_to_struct_name(table_name)} is a row of the {table_name} table')
+        lines.append(f'message {table_name_to_struct_name(table_name)} {{')
+        for number, field in enumerate(get_struct_fields(table_data['schema']), start=1):
+            label = 'optional ' if field.get('nullable', True) else ''
+            proto_type = datatype_to_proto(field.get('datatype', 'string'))
+            lines.append(f'  {label}{proto_type} {to_snake_case(field["name"])} = {number};')
+        lines.append('}')
+
+    lines.append('')
+    return '\n'.join(lines)
+
+
 def generate_main_go(tables_with_calc: list) -> str:
     """Generate main.go content that processes ALL tables with calculated fields.
 
@@ -515,16 +704,37 @@ def generate_main_go(tables_with_calc: list) -> str:
     lines.append('\t"path/filepath"')
     lines.append(')')
     lines.append('')
+    lines.append('// RunnerTables lists the tables this runner processes (its "Expected tables")')
+    table_literals = ', '.join(f'"{t}"' for t in tables_with_calc)
+    lines.append(f'var RunnerTables = []string{{{table_literals}}}')
+    lines.append('')
     lines.append('func main() {')
+    lines.append('\t// Subcommands are hand-written in erb_commands.go; with no arguments, take the test')
+    lines.append('\tif len(os.Args) > 1 {')
+    lines.append('\t\tos.Exit(runCommand(os.Args[1], os.Args[2:]))')
+    lines.append('\t}')
+    lines.append('')
     lines.append('\tscriptDir, err := os.Getwd()')
     lines.append('\tif err != nil {')
     lines.append('\t\tfmt.Fprintf(os.Stderr, "FATAL: Failed to get working directory: %v\\n", err)')
     lines.append('\t\tos.Exit(1)')
     lines.append('\t}')
     lines.append('')
-    lines.append('\t// Shared blank-tests directory at project root')
+    lines.append('\t// Shared blank-tests directory at project root; ERB_BLANK_TESTS_DIR and')
+    lines.append('\t// ERB_TEST_ANSWERS_DIR override the defaults (e.g. in a CI container)')
     lines.append('\tblankTestsDir := filepath.Join(scriptDir, "..", "..", "testing", "blank-tests")')
+    lines.append('\tif dir := os.Getenv("ERB_BLANK_TESTS_DIR"); dir != "" {')
+    lines.append('\t\tblankTestsDir = dir')
+    lines.append('\t}')
     lines.append('\ttestAnswersDir := filepath.Join(scriptDir, "test-answers")')
+    lines.append('\tif dir := os.Getenv("ERB_TEST_ANSWERS_DIR"); dir != "" {')
+    lines.append('\t\ttestAnswersDir = dir')
+    lines.append('\t}')
+    lines.append('')
+    lines.append('\tif info, err := os.Stat(blankTestsDir); err != nil || !info.IsDir() {')
+    lines.append('\t\tfmt.Fprintf(os.Stderr, "FATAL: blank-tests directory %s does not exist (set ERB_BLANK_TESTS_DIR)\\n", blankTestsDir)')
+    lines.append('\t\tos.Exit(1)')
+    lines.append('\t}')
     lines.append('')
     lines.append('\t// Ensure output directory exists')
     lines.append('\tif err := os.MkdirAll(testAnswersDir, 0755); err != nil {')
@@ -606,6 +816,7 @@ def main():
     # Note: erb_test, test-answers/, test-results.md are build/test outputs
     GENERATED_FILES = [
         'erb_sdk.go',
+        'erb_sdk.proto',
         'main.go',
     ]
 
@@ -658,12 +869,20 @@ def main():
 
     # Generate erb_sdk.go
     print("Generating erb_sdk.go...")
-    erb_sdk_content = generate_erb_sdk(rulebook)
+    rulebook_checksum = hashlib.sha256(get_rulebook_path().read_bytes()).hexdigest()
+    erb_sdk_content = generate_erb_sdk(rulebook, rulebook_checksum, generator_checksum(script_dir))
 
     erb_sdk_path = script_dir / "erb_sdk.go"
     erb_sdk_path.write_text(erb_sdk_content, encoding='utf-8')
     print(f"Wrote: {erb_sdk_path} ({len(erb_sdk_content)} bytes)")
 
+    # Generate erb_sdk.proto alongside, from the same struct field order
+    print("Generating erb_sdk.proto...")
+    erb_proto_content = generate_erb_sdk_proto(rulebook, rulebook_checksum)
+    erb_proto_path = script_dir / "erb_sdk.proto"
+    erb_proto_path.write_text(erb_proto_content, encoding='utf-8')
+    print(f"Wrote: {erb_proto_path} ({len(erb_proto_content)} bytes)")
+
     # Generate main.go - ALWAYS regenerated to stay in sync with erb_sdk.go
     # IMPORTANT: Now processes ALL tables with calculated fields, not just one!
     main_go_path = script_dir / "main.go"
@@ -16,16 +16,36 @@ import (
 	"path/filepath"
 )
 
+// RunnerTables lists the tables this runner processes (its "Expected tables")
+var RunnerTables = []string{"LanguageCandidates"}
+
 func main() {
+	// Subcommands are hand-written in erb_commands.go; with no arguments, take the test
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1], os.Args[2:]))
+	}
+
 	scriptDir, err := os.Getwd()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "FATAL: Failed to get working directory: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Shared blank-tests directory at project root
+	// Shared blank-tests directory at project root; ERB_BLANK_TESTS_DIR and
+	// ERB_TEST_ANSWERS_DIR override the defaults (e.g. in a CI container)
 	blankTestsDir := filepath.Join(scriptDir, "..", "..", "testing", "blank-tests")
+	if dir := os.Getenv("ERB_BLANK_TESTS_DIR"); dir != "" {
+		blankTestsDir = dir
+	}
 	testAnswersDir := filepath.Join(scriptDir, "test-answers")
+	if dir := os.Getenv("ERB_TEST_ANSWERS_DIR"); dir != "" {
+		testAnswersDir = dir
+	}
+
+	if info, err := os.Stat(blankTestsDir); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "FATAL: blank-tests directory %s does not exist (set ERB_BLANK_TESTS_DIR)\n", blankTestsDir)
+		os.Exit(1)
+	}
 
 	// Ensure output directory exists
 	if err := os.MkdirAll(testAnswersDir, 0755); err != nil {
@@ -38,9 +38,13 @@ cd "$SCRIPT_DIR"
     # Ensure test-answers directory exists
     mkdir -p "$SCRIPT_DIR/test-answers"
 
+    # Refuse to run stale generated code (rulebook or generator changed since inject-into-golang.py ran)
+    echo "golang: Checking erb_sdk.go is up to date..."
+    go run . verify-generated
+
     # Run Go test runner - compilation errors will cause immediate exit due to set -e
     echo "golang: Compiling and running..."
-    go run erb_sdk.go main.go
+    go run .
 
     echo ""
 } 2>&1 | tee "$LOG_FILE"